@@ -2,7 +2,7 @@ package mmio
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -13,6 +13,19 @@ func ReadInts(fp string) ([]int, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseInts(sa)
+}
+
+// ReadIntsFrom reads the lines of r, see ReadInts
+func ReadIntsFrom(r io.Reader) ([]int, error) {
+	sa, err := ReadTextLinesFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseInts(sa)
+}
+
+func parseInts(sa []string) ([]int, error) {
 	da := make([]int, len(sa))
 	for i, ln := range sa {
 		d, err := strconv.Atoi(ln)
@@ -26,19 +39,16 @@ func ReadInts(fp string) ([]int, error) {
 
 // WriteInts is a simple routine that writes an integer slice to an ascii file
 func WriteInts(fp string, d []int) error {
-	f, err := os.OpenFile(fp, os.O_CREATE|os.O_WRONLY, 0644)
-	// f, err := os.OpenFile(fp, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) // append
-	if err != nil {
-		return err
-	}
+	return writeOutput(fp, false, func(f io.Writer) error { return WriteIntsTo(f, d) })
+}
+
+// WriteIntsTo writes to f, see WriteInts
+func WriteIntsTo(f io.Writer, d []int) error {
 	for _, v := range d {
 		if _, err := f.Write([]byte(fmt.Sprintf("%d\n", v))); err != nil {
 			return err
 		}
 	}
-	if err := f.Close(); err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -48,6 +58,19 @@ func ReadFloats(fp string) ([]float64, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseFloats(sa)
+}
+
+// ReadFloatsFrom reads the lines of r, see ReadFloats
+func ReadFloatsFrom(r io.Reader) ([]float64, error) {
+	sa, err := ReadTextLinesFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseFloats(sa)
+}
+
+func parseFloats(sa []string) ([]float64, error) {
 	da := make([]float64, len(sa))
 	for i, ln := range sa {
 		d, err := strconv.ParseFloat(ln, 64)
@@ -65,6 +88,19 @@ func ReadTabFloats(fp string) ([][]float64, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseTabFloats(sa)
+}
+
+// ReadTabFloatsFrom reads the lines of r, see ReadTabFloats
+func ReadTabFloatsFrom(r io.Reader) ([][]float64, error) {
+	sa, err := ReadTextLinesFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseTabFloats(sa)
+}
+
+func parseTabFloats(sa []string) ([][]float64, error) {
 	da := make([][]float64, len(sa))
 	for i, ln := range sa {
 		stp := strings.Split(RemoveWhiteSpaces(ln), " ")
@@ -82,19 +118,16 @@ func ReadTabFloats(fp string) ([][]float64, error) {
 
 // WriteFloats is a simple routine that writes an float slice to an ascii file
 func WriteFloats(fp string, d []float64) error {
-	f, err := os.OpenFile(fp, os.O_CREATE|os.O_WRONLY, 0644)
-	// f, err := os.OpenFile(fp, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) // append
-	if err != nil {
-		return err
-	}
+	return writeOutput(fp, false, func(f io.Writer) error { return WriteFloatsTo(f, d) })
+}
+
+// WriteFloatsTo writes to f, see WriteFloats
+func WriteFloatsTo(f io.Writer, d []float64) error {
 	for _, v := range d {
 		if _, err := f.Write([]byte(fmt.Sprintf("%f\n", v))); err != nil {
 			return err
 		}
 	}
-	if err := f.Close(); err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -106,14 +139,18 @@ func WriteLines(fp string, s []string) error {
 	return WriteStrings(fp, s)
 }
 
+// WriteLinesAtomic is WriteLines, replacing fp only once writing has succeeded (see AtomicWrites)
+func WriteLinesAtomic(fp string, s []string) error {
+	return writeOutput(fp, true, func(f io.Writer) error { return WriteStringsTo(f, s) })
+}
+
 // WriteStrings is a simple routine that writes a slice of strings to an ascii file
 func WriteStrings(fp string, s []string) error {
-	f, err := os.Create(fp)
-	// f, err := os.OpenFile(fp, os.O_CREATE|os.O_WRONLY, 0644)
-	// f, err := os.OpenFile(fp, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) // append
-	if err != nil {
-		return err
-	}
+	return writeOutput(fp, false, func(f io.Writer) error { return WriteStringsTo(f, s) })
+}
+
+// WriteStringsTo writes to f, see WriteStrings
+func WriteStringsTo(f io.Writer, s []string) error {
 	for _, v := range s {
 		if len(v) == 0 {
 			continue
@@ -122,23 +159,20 @@ func WriteStrings(fp string, s []string) error {
 			return err
 		}
 	}
-	if err := f.Close(); err != nil {
-		return err
-	}
 	return nil
 }
 
 func WriteString(fp, content string) error {
-	f, err := os.OpenFile(fp, os.O_CREATE|os.O_WRONLY, 0644)
-	// f, err := os.OpenFile(fp, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) // append
-	if err != nil {
-		return err
-	}
-	if _, err := f.Write([]byte(content)); err != nil {
-		return err
-	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-	return nil
+	return writeOutput(fp, false, func(f io.Writer) error { return WriteStringTo(f, content) })
+}
+
+// WriteStringAtomic is WriteString, replacing fp only once writing has succeeded (see AtomicWrites)
+func WriteStringAtomic(fp, content string) error {
+	return writeOutput(fp, true, func(f io.Writer) error { return WriteStringTo(f, content) })
+}
+
+// WriteStringTo writes to f, see WriteString
+func WriteStringTo(f io.Writer, content string) error {
+	_, err := f.Write([]byte(content))
+	return err
 }
@@ -0,0 +1,185 @@
+package mmio
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// AtomicWrites, when set, makes every path-based writer (WriteBinary, WriteString, WriteLines,
+// WriteIMAP, NewCSVwriter, NewTXTwriter, ...) write to a temporary file in the target's
+// directory that is synced and renamed over the target only once writing has succeeded.
+// It is read without synchronization and applies process-wide: set it once at startup, before
+// any writer runs. To make individual outputs atomic, use the ...Atomic variants instead
+// (WriteBinaryAtomic, WriteStringAtomic, WriteLinesAtomic, WriteIMAPAtomic, NewCSVwriterAtomic,
+// NewTXTwriterAtomic).
+var AtomicWrites = false
+
+// AtomicFile is a temporary file that replaces its target on Close, or is removed by Abort
+type AtomicFile struct {
+	*os.File
+	target string
+	done   bool
+}
+
+// CreateAtomic creates a temporary file next to fp that will replace fp on Close
+func CreateAtomic(fp string) (*AtomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(fp), "."+filepath.Base(fp)+".tmp-*")
+	if err != nil {
+		return nil, pathError("CreateAtomic", fp, err)
+	}
+	return &AtomicFile{File: f, target: fp}, nil
+}
+
+// Close syncs the temporary file and renames it over the target. The target keeps
+// its permissions if it already exists (0644 otherwise).
+func (a *AtomicFile) Close() error {
+	if a.done {
+		return nil
+	}
+	a.done = true
+	tmp := a.File.Name()
+	fail := func(err error) error {
+		a.File.Close()
+		os.Remove(tmp)
+		return pathError("AtomicFile.Close", a.target, err)
+	}
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(a.target); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	if err := a.File.Chmod(perm); err != nil {
+		return fail(err)
+	}
+	if err := a.File.Sync(); err != nil {
+		return fail(err)
+	}
+	if _, err := Backups.backup(a.target, true); err != nil {
+		return fail(err)
+	}
+	if err := a.File.Close(); err != nil {
+		os.Remove(tmp)
+		return pathError("AtomicFile.Close", a.target, err)
+	}
+	if err := os.Rename(tmp, a.target); err != nil {
+		os.Remove(tmp)
+		return pathError("AtomicFile.Close", a.target, err)
+	}
+	syncDir(filepath.Dir(a.target))
+	return nil
+}
+
+// Abort closes and removes the temporary file, leaving the target untouched
+func (a *AtomicFile) Abort() error {
+	if a.done {
+		return nil
+	}
+	a.done = true
+	a.File.Close()
+	if err := os.Remove(a.File.Name()); err != nil && !os.IsNotExist(err) {
+		return pathError("AtomicFile.Abort", a.target, err)
+	}
+	return nil
+}
+
+// syncDir flushes a directory entry change (the rename) to disk, where supported
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// WriteFileAtomic writes data to a temporary file and renames it over fp
+func WriteFileAtomic(fp string, data []byte) error {
+	a, err := CreateAtomic(fp)
+	if err != nil {
+		return err
+	}
+	if _, err := a.Write(data); err != nil {
+		a.Abort()
+		return pathError("WriteFileAtomic", fp, err)
+	}
+	return a.Close()
+}
+
+// outputFile is a file being written, finalized by Close or discarded by Abort
+type outputFile interface {
+	io.Writer
+	Close() error
+	Abort() error
+}
+
+// plainFile is a non-atomic outputFile; Abort closes it and puts back the version it replaced, if backed up
+type plainFile struct {
+	*os.File
+	backup string
+}
+
+func (p plainFile) Abort() error {
+	err := p.File.Close()
+	if rerr := restoreBackup(p.backup, p.File.Name()); rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+// restoreBackup moves the backup bk (see BackupPolicy) back to fp after a failed write
+func restoreBackup(bk, fp string) error {
+	if len(bk) == 0 {
+		return nil
+	}
+	if err := os.Rename(bk, fp); err != nil {
+		return pathError("restoreBackup", fp, err)
+	}
+	return nil
+}
+
+// createOutput creates fp for writing, atomically if requested or if AtomicWrites is set,
+// backing up any existing file according to Backups (the backup is put back if creation fails or
+// the output is aborted)
+func createOutput(fp string, atomic bool) (outputFile, error) {
+	if atomic || AtomicWrites {
+		return CreateAtomic(fp)
+	}
+	bk, err := Backups.backup(fp, false)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(fp)
+	if err != nil {
+		restoreBackup(bk, fp)
+		return nil, err
+	}
+	return plainFile{f, bk}, nil
+}
+
+// writeFile replaces os.WriteFile for the path-based writers, atomically if requested or if
+// AtomicWrites is set, honouring Backups
+func writeFile(fp string, data []byte, perm os.FileMode, atomic bool) error {
+	if atomic || AtomicWrites {
+		return WriteFileAtomic(fp, data)
+	}
+	bk, err := Backups.backup(fp, false)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(fp, data, perm); err != nil {
+		restoreBackup(bk, fp)
+		return err
+	}
+	return nil
+}
+
+// writeOutput creates fp (see createOutput) and hands it to write; the file is discarded (atomic mode) if write fails
+func writeOutput(fp string, atomic bool, write func(w io.Writer) error) error {
+	f, err := createOutput(fp, atomic)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,123 @@
+package mmio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteOutputAtomic(t *testing.T) {
+	failed := errors.New("write failed")
+	tests := []struct {
+		name     string
+		existing bool
+		perm     os.FileMode
+		fail     bool
+		want     string
+		wantPerm os.FileMode
+	}{
+		{"new file", false, 0, false, "new", 0644},
+		{"replace, keep permissions", true, 0600, false, "new", 0600},
+		{"failed write keeps the target", true, 0644, true, "old", 0644},
+		{"failed write creates nothing", false, 0, true, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withBackups(t, BackupPolicy{}, false) // atomic per call, not through AtomicWrites
+			dir := t.TempDir()
+			fp := filepath.Join(dir, "out.txt")
+			if tt.existing {
+				if err := os.WriteFile(fp, []byte("old"), tt.perm); err != nil {
+					t.Fatal(err)
+				}
+				os.Chmod(fp, tt.perm) // umask
+			}
+			err := writeOutput(fp, true, func(w io.Writer) error {
+				io.WriteString(w, "new")
+				if b, _ := os.ReadFile(fp); tt.existing && string(b) != "old" {
+					t.Errorf("target changed to %q while writing", b)
+				}
+				if tt.fail {
+					return failed
+				}
+				return nil
+			})
+			if tt.fail != errors.Is(err, failed) {
+				t.Fatalf("error %v", err)
+			}
+			b, err := os.ReadFile(fp)
+			if len(tt.want) == 0 {
+				if !os.IsNotExist(err) {
+					t.Errorf("target created: %q, %v", b, err)
+				}
+			} else if string(b) != tt.want {
+				t.Errorf("target holds %q, want %q", b, tt.want)
+			} else if fi, _ := os.Stat(fp); runtime.GOOS != "windows" && fi.Mode().Perm() != tt.wantPerm {
+				t.Errorf("permissions %v, want %v", fi.Mode().Perm(), tt.wantPerm)
+			}
+			if des, _ := os.ReadDir(dir); len(des) > 1 || (len(des) == 1 && des[0].Name() != "out.txt") {
+				t.Errorf("temporary files left: %v", des)
+			}
+		})
+	}
+}
+
+func TestCSVwriterAtomic(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "a.csv")
+	os.WriteFile(fp, []byte("old\n"), 0644)
+	for _, abort := range []bool{true, false} {
+		w, err := NewCSVwriterAtomic(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHead("a,b")
+		w.WriteLine(1, 2)
+		if abort {
+			err = w.Abort()
+		} else {
+			err = w.CloseE()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "a,b\n1,2\n"
+		if abort {
+			want = "old\n"
+		}
+		if b, _ := os.ReadFile(fp); string(b) != want {
+			t.Errorf("abort %v: target holds %q, want %q", abort, b, want)
+		}
+	}
+}
+
+func TestWriteAtomicVariants(t *testing.T) {
+	withBackups(t, BackupPolicy{}, false)
+	tests := []struct {
+		name  string
+		write func(fp string) error
+	}{
+		{"WriteBinaryAtomic", func(fp string) error { return WriteBinaryAtomic(fp, int32(1), 2.) }},
+		{"WriteStringAtomic", func(fp string) error { return WriteStringAtomic(fp, "a\n") }},
+		{"WriteLinesAtomic", func(fp string) error { return WriteLinesAtomic(fp, []string{"a", "b"}) }},
+		{"WriteIMAPAtomic", func(fp string) error { return WriteIMAPAtomic(fp, map[int]int{1: 2}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fp := filepath.Join(dir, "out")
+			os.WriteFile(fp, []byte("old"), 0644)
+			if err := tt.write(fp); err != nil {
+				t.Fatal(err)
+			}
+			if b, _ := os.ReadFile(fp); string(b) == "old" {
+				t.Errorf("target not replaced")
+			}
+			if des, _ := os.ReadDir(dir); len(des) != 1 {
+				t.Errorf("temporary files left: %v", des)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package mmio
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BackupMode selects how existing outputs are renamed before being overwritten
+type BackupMode int
+
+const (
+	BackupNone        BackupMode = iota
+	BackupTimestamped            // "out.csv.yymmddhhmmss" (MMtime of the replaced file), "-2", "-3", ... appended on collision
+	BackupNumbered               // "out.csv.1" (newest), "out.csv.2", ...
+)
+
+// BackupPolicy keeps the previous versions of a file that is about to be overwritten
+type BackupPolicy struct {
+	Mode BackupMode
+	Keep int // number of backups retained per file, older ones are removed; 0: keep all
+}
+
+// Backups is applied by every path-based writer (NewTXTwriter, NewCSVwriter, WriteBinary,
+// WriteString, XLSXwriter.Close, ...) and by GetInstance before an existing file is replaced.
+// In atomic mode (see AtomicWrites) the backup is a hard link (or copy) made just before the
+// rename, so the target never goes missing. Like AtomicWrites, it is read without
+// synchronization: set it once at startup, before any writer runs.
+var Backups = BackupPolicy{}
+
+// Backup applies the policy to fp, returning the backup created ("" if fp does not exist or Mode is BackupNone)
+func (p BackupPolicy) Backup(fp string) (string, error) {
+	return p.backup(fp, false)
+}
+
+// backup moves fp to its backup name, or links/copies it there when fp must stay in place
+func (p BackupPolicy) backup(fp string, keep bool) (string, error) {
+	if p.Mode == BackupNone {
+		return "", nil
+	}
+	fi, err := os.Stat(fp)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", pathError("Backup", fp, err)
+	} else if !fi.Mode().IsRegular() {
+		return "", nil
+	}
+
+	var bk string
+	switch p.Mode {
+	case BackupTimestamped:
+		stamp := fp + "." + MMtime(fi.ModTime())
+		bk = stamp
+		for k := 2; ; k++ {
+			if _, err := os.Lstat(bk); os.IsNotExist(err) {
+				break
+			}
+			bk = stamp + "-" + strconv.Itoa(k)
+		}
+	case BackupNumbered:
+		bs, err := listBackups(fp, BackupNumbered)
+		if err != nil {
+			return "", err
+		}
+		for i := len(bs) - 1; i >= 0; i-- { // shift, highest first
+			if p.Keep > 0 && bs[i].n >= p.Keep {
+				err = os.Remove(bs[i].path)
+			} else {
+				err = os.Rename(bs[i].path, fp+"."+strconv.Itoa(bs[i].n+1))
+			}
+			if err != nil {
+				return "", pathError("Backup", fp, err)
+			}
+		}
+		bk = fp + ".1"
+	default:
+		return "", nil
+	}
+
+	if keep {
+		if err := os.Link(fp, bk); err != nil {
+			if _, err := CopyFileE(fp, bk); err != nil {
+				return "", pathError("Backup", fp, err)
+			}
+			os.Chtimes(bk, fi.ModTime(), fi.ModTime())
+		}
+	} else if err := os.Rename(fp, bk); err != nil {
+		return "", pathError("Backup", fp, err)
+	}
+
+	if p.Mode == BackupTimestamped && p.Keep > 0 {
+		bs, err := listBackups(fp, BackupTimestamped)
+		if err != nil {
+			return bk, err
+		}
+		for i := 0; i < len(bs)-p.Keep; i++ {
+			if err := os.Remove(bs[i].path); err != nil {
+				return bk, pathError("Backup", fp, err)
+			}
+		}
+	}
+	return bk, nil
+}
+
+type backupFile struct {
+	path  string
+	stamp string // BackupTimestamped
+	n     int    // BackupNumbered: number; BackupTimestamped: collision suffix
+}
+
+// listBackups returns the existing backups of fp, oldest first for timestamped
+// backups and by increasing number for numbered ones
+func listBackups(fp string, mode BackupMode) ([]backupFile, error) {
+	dir, prefix := filepath.Dir(fp), filepath.Base(fp)+"."
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, pathError("Backup", fp, err)
+	}
+	digits := func(s string) bool {
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return len(s) > 0
+	}
+	var bs []backupFile
+	for _, de := range des {
+		sfx, ok := strings.CutPrefix(de.Name(), prefix)
+		if !ok || de.IsDir() {
+			continue
+		}
+		b := backupFile{path: filepath.Join(dir, de.Name())}
+		switch mode {
+		case BackupNumbered:
+			if !digits(sfx) || sfx[0] == '0' {
+				continue
+			}
+			b.n, _ = strconv.Atoi(sfx)
+		case BackupTimestamped:
+			stamp, k, _ := strings.Cut(sfx, "-")
+			if len(stamp) != 12 || !digits(stamp) || (len(k) > 0 && !digits(k)) {
+				continue
+			}
+			b.stamp, b.n = stamp, 1
+			if len(k) > 0 {
+				b.n, _ = strconv.Atoi(k)
+			}
+		}
+		bs = append(bs, b)
+	}
+	sort.Slice(bs, func(i, j int) bool {
+		if bs[i].stamp != bs[j].stamp {
+			return bs[i].stamp < bs[j].stamp
+		}
+		return bs[i].n < bs[j].n
+	})
+	return bs, nil
+}
@@ -0,0 +1,102 @@
+package mmio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func withBackups(t *testing.T, p BackupPolicy, atomic bool) {
+	b, a := Backups, AtomicWrites
+	Backups, AtomicWrites = p, atomic
+	t.Cleanup(func() { Backups, AtomicWrites = b, a })
+}
+
+func TestBackupRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy BackupPolicy
+		atomic bool
+		writes int
+		want   []string // contents of the backups, oldest first
+	}{
+		{"numbered", BackupPolicy{BackupNumbered, 2}, false, 4, []string{"1", "2"}},
+		{"numbered atomic", BackupPolicy{BackupNumbered, 2}, true, 4, []string{"1", "2"}},
+		{"numbered keep all", BackupPolicy{BackupNumbered, 0}, false, 4, []string{"0", "1", "2"}},
+		{"timestamped", BackupPolicy{BackupTimestamped, 2}, false, 4, []string{"1", "2"}},
+		{"timestamped atomic", BackupPolicy{BackupTimestamped, 3}, true, 5, []string{"1", "2", "3"}},
+		{"none", BackupPolicy{}, false, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withBackups(t, tt.policy, tt.atomic)
+			fp := filepath.Join(t.TempDir(), "out.txt")
+			for i := 0; i < tt.writes; i++ {
+				if err := WriteString(fp, strconv.Itoa(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if b, _ := os.ReadFile(fp); string(b) != strconv.Itoa(tt.writes-1) {
+				t.Fatalf("target holds %q", b)
+			}
+			bs, err := listBackups(fp, tt.policy.Mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.policy.Mode == BackupNumbered { // highest number is oldest
+				for i, j := 0, len(bs)-1; i < j; i, j = i+1, j-1 {
+					bs[i], bs[j] = bs[j], bs[i]
+				}
+			}
+			if tt.policy.Mode == BackupNone {
+				bs = nil
+			}
+			if len(bs) != len(tt.want) {
+				t.Fatalf("%d backups, want %d", len(bs), len(tt.want))
+			}
+			for i, b := range bs {
+				if c, _ := os.ReadFile(b.path); string(c) != tt.want[i] {
+					t.Errorf("backup %s holds %q, want %q", b.path, c, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBackupRestoredOnFailure(t *testing.T) {
+	for _, atomic := range []bool{false, true} {
+		withBackups(t, BackupPolicy{BackupNumbered, 3}, atomic)
+		fp := filepath.Join(t.TempDir(), "out.txt")
+		if err := WriteString(fp, "good"); err != nil {
+			t.Fatal(err)
+		}
+		boom := errors.New("boom")
+		err := writeOutput(fp, false, func(w io.Writer) error {
+			io.WriteString(w, "partial")
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("atomic=%v: got %v", atomic, err)
+		}
+		if b, _ := os.ReadFile(fp); string(b) != "good" {
+			t.Fatalf("atomic=%v: target holds %q after a failed write", atomic, b)
+		}
+		if bs, _ := listBackups(fp, BackupNumbered); len(bs) != 0 {
+			t.Fatalf("atomic=%v: %d backups left after a failed write", atomic, len(bs))
+		}
+	}
+}
+
+func TestLoggerBackup(t *testing.T) {
+	withBackups(t, BackupPolicy{BackupNumbered, 1}, false)
+	fp := filepath.Join(t.TempDir(), "mm.log")
+	os.WriteFile(fp, []byte("previous run\n"), 0644)
+	l := createLogger(fp)
+	l.Println("new run")
+	if b, _ := os.ReadFile(fp + ".1"); string(b) != "previous run\n" {
+		t.Fatalf("backup holds %q", b)
+	}
+}
@@ -25,7 +25,7 @@ import (
 
 // OpenBinary creates reader from filepath
 func OpenBinary(filepath string) *bytes.Reader {
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		log.Fatalf("Fatal error: binary.OpenBinary failed: %v\n", err)
 	}
@@ -34,14 +34,18 @@ func OpenBinary(filepath string) *bytes.Reader {
 
 // ReadBinary general binary reader
 func ReadBinary(filepath string, data ...interface{}) error {
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		fmt.Printf("ReadBinary failed: %v\n", err)
 		return fmt.Errorf("os.ReadFile failed: %v", err)
 	}
-	buf := bytes.NewReader(b)
+	return ReadBinaryFrom(bytes.NewReader(b), data...)
+}
+
+// ReadBinaryFrom reads data from r in sequence (little-endian), see ReadBinary
+func ReadBinaryFrom(r io.Reader, data ...interface{}) error {
 	for _, v := range data {
-		err := binary.Read(buf, binary.LittleEndian, v)
+		err := binary.Read(r, binary.LittleEndian, v)
 		if err != nil {
 			fmt.Printf("ReadBinary failed: %v\n", err)
 			return fmt.Errorf("binary.Read failed: %v", err)
@@ -195,17 +199,28 @@ func lineParser(r rune) bool {
 
 // ReadBinaryFloats reads an entire file and returns a slice of floats
 func ReadBinaryFloats(filepath string) ([]float64, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		fmt.Printf("ReadBinaryFloats failed: %v\n", err)
 		return nil, fmt.Errorf("os.ReadFile failed: %v", err)
 	}
+	return binaryFloats(b)
+}
+
+// ReadBinaryFloatsFrom reads r to its end and returns a slice of floats
+func ReadBinaryFloatsFrom(r io.Reader) ([]float64, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ReadBinaryFloatsFrom: %v", err)
+	}
+	return binaryFloats(b)
+}
+
+func binaryFloats(b []byte) ([]float64, error) {
 	buf := bytes.NewReader(b)
 	n := len(b) / 8
 	a := make([]float64, n)
-	err = binary.Read(buf, binary.LittleEndian, a)
-	if err != nil {
+	if err := binary.Read(buf, binary.LittleEndian, a); err != nil {
 		fmt.Printf("ReadBinaryFloats failed: %v\n", err)
 		return nil, fmt.Errorf("binary.Read failed: %v", err)
 	}
@@ -214,107 +229,105 @@ func ReadBinaryFloats(filepath string) ([]float64, error) {
 
 // ReadBinaryFloats reads an entire file and returns a slice of d dimensions
 func ReadBinaryFloat64s(filepath string, d int) ([][]float64, int, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		fmt.Printf("ReadBinaryFloats failed: %v\n", err)
 		return nil, 0, fmt.Errorf("os.ReadFile failed: %v", err)
 	}
-	buf := bytes.NewReader(b)
-	n := len(b) / 8 / d
-	a := make([][]float64, d)
-	for i := 0; i < d; i++ {
-		v := make([]float64, n)
-		err := binary.Read(buf, binary.LittleEndian, v)
-		if err != nil {
-			fmt.Printf("ReadBinaryFloats failed: %v\n", err)
-			return nil, 0, fmt.Errorf("binary.Read failed: %v", err)
-		}
-		a[i] = v
+	return binarySlices[float64](b, d, "ReadBinaryFloat64s")
+}
+
+// ReadBinaryFloat64sFrom reads r to its end and returns a slice of d dimensions
+func ReadBinaryFloat64sFrom(r io.Reader, d int) ([][]float64, int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ReadBinaryFloat64sFrom: %v", err)
 	}
-	return a, n, nil
+	return binarySlices[float64](b, d, "ReadBinaryFloat64s")
 }
 
 // ReadBinaryFloat32s reads an entire file and returns a slice of d dimensions
 func ReadBinaryFloat32s(filepath string, d int) ([][]float32, int, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		fmt.Printf("ReadBinaryFloats failed: %v\n", err)
 		return nil, 0, fmt.Errorf("os.ReadFile failed: %v", err)
 	}
-	buf := bytes.NewReader(b)
-	n := len(b) / 4 / d
-	a := make([][]float32, d)
-	for i := 0; i < d; i++ {
-		v := make([]float32, n)
-		err := binary.Read(buf, binary.LittleEndian, v)
-		if err != nil {
-			fmt.Printf("ReadBinaryFloats failed: %v\n", err)
-			return nil, 0, fmt.Errorf("binary.Read failed: %v", err)
-		}
-		a[i] = v
+	return binarySlices[float32](b, d, "ReadBinaryFloat32s")
+}
+
+// ReadBinaryFloat32sFrom reads r to its end and returns a slice of d dimensions
+func ReadBinaryFloat32sFrom(r io.Reader, d int) ([][]float32, int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ReadBinaryFloat32sFrom: %v", err)
 	}
-	return a, n, nil
+	return binarySlices[float32](b, d, "ReadBinaryFloat32s")
 }
 
 // ReadBinaryInts reads an entire file and returns a slice of d dimensions
 func ReadBinaryInts(filepath string, d int) ([][]int32, int, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		return nil, 0, fmt.Errorf("ReadBinaryInts: os.ReadFile failed: %v", err)
 	}
-	buf := bytes.NewReader(b)
-	n := len(b) / 4 / d
-	a := make([][]int32, d)
-	for i := 0; i < d; i++ {
-		v := make([]int32, n)
-		err := binary.Read(buf, binary.LittleEndian, v)
-		if err != nil {
-			return nil, 0, fmt.Errorf("ReadBinaryInts: binary.Read failed: %v", err)
-		}
-		a[i] = v
+	return binarySlices[int32](b, d, "ReadBinaryInts")
+}
+
+// ReadBinaryIntsFrom reads r to its end and returns a slice of d dimensions
+func ReadBinaryIntsFrom(r io.Reader, d int) ([][]int32, int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ReadBinaryIntsFrom: %v", err)
 	}
-	return a, n, nil
+	return binarySlices[int32](b, d, "ReadBinaryInts")
 }
 
 // ReadBinaryShorts reads an entire file and returns a slice of d dimensions
 func ReadBinaryShorts(filepath string, d int) ([][]int16, int, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		return nil, 0, fmt.Errorf("ReadBinaryShorts: os.ReadFile failed: %v", err)
 	}
-	buf := bytes.NewReader(b)
-	n := len(b) / 2 / d
-	a := make([][]int16, d)
-	for i := 0; i < d; i++ {
-		v := make([]int16, n)
-		err := binary.Read(buf, binary.LittleEndian, v)
-		if err != nil {
-			return nil, 0, fmt.Errorf("ReadBinaryShorts: binary.Read failed: %v", err)
-		}
-		a[i] = v
+	return binarySlices[int16](b, d, "ReadBinaryShorts")
+}
+
+// ReadBinaryShortsFrom reads r to its end and returns a slice of d dimensions
+func ReadBinaryShortsFrom(r io.Reader, d int) ([][]int16, int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ReadBinaryShortsFrom: %v", err)
 	}
-	return a, n, nil
+	return binarySlices[int16](b, d, "ReadBinaryShorts")
 }
 
 // ReadBinaryBytes reads an entire file and returns a slice of d dimensions
 func ReadBinaryBytes(filepath string, d int) ([][]uint8, int, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		return nil, 0, fmt.Errorf("ReadBinaryBytes: os.ReadFile failed: %v", err)
 	}
+	return binarySlices[uint8](b, d, "ReadBinaryBytes")
+}
+
+// ReadBinaryBytesFrom reads r to its end and returns a slice of d dimensions
+func ReadBinaryBytesFrom(r io.Reader, d int) ([][]uint8, int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ReadBinaryBytesFrom: %v", err)
+	}
+	return binarySlices[uint8](b, d, "ReadBinaryBytes")
+}
+
+// binarySlices splits little-endian values into d consecutive slices of equal length
+func binarySlices[T float64 | float32 | int32 | int16 | uint8](b []byte, d int, fn string) ([][]T, int, error) {
 	buf := bytes.NewReader(b)
-	n := len(b) / d
-	a := make([][]uint8, d)
+	n := len(b) / binary.Size(T(0)) / d
+	a := make([][]T, d)
 	for i := 0; i < d; i++ {
-		v := make([]uint8, n)
-		err := binary.Read(buf, binary.LittleEndian, v)
-		if err != nil {
-			return nil, 0, fmt.Errorf("ReadBinaryBytes: binary.Read failed: %v", err)
+		v := make([]T, n)
+		if err := binary.Read(buf, binary.LittleEndian, v); err != nil {
+			return nil, 0, fmt.Errorf("%s: binary.Read failed: %v", fn, err)
 		}
 		a[i] = v
 	}
@@ -323,11 +336,23 @@ func ReadBinaryBytes(filepath string, d int) ([][]uint8, int, error) {
 
 // ReadBinaryIMAP reads a map[int]int for an entire file
 func ReadBinaryIMAP(filepath string) (map[int]int, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("ReadBinaryIMAP: os.ReadFile failed: %v", err)
 	}
+	return binaryIMAP(b)
+}
+
+// ReadBinaryIMAPFrom reads a map[int]int from r to its end
+func ReadBinaryIMAPFrom(r io.Reader) (map[int]int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ReadBinaryIMAPFrom: %v", err)
+	}
+	return binaryIMAP(b)
+}
+
+func binaryIMAP(b []byte) (map[int]int, error) {
 	buf := bytes.NewReader(b)
 	n := len(b) / 8
 	m := make(map[int]int, n)
@@ -343,11 +368,23 @@ func ReadBinaryIMAP(filepath string) (map[int]int, error) {
 
 // ReadBinaryRMAP reads a map[int]float64 for an entire file
 func ReadBinaryRMAP(filepath string) (map[int]float64, error) {
-	var err error
-	b, err := os.ReadFile(filepath)
+	b, err := readFile(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("ReadBinaryRMAP: os.ReadFile failed: %v", err)
 	}
+	return binaryRMAP(b)
+}
+
+// ReadBinaryRMAPFrom reads a map[int]float64 from r to its end
+func ReadBinaryRMAPFrom(r io.Reader) (map[int]float64, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ReadBinaryRMAPFrom: %v", err)
+	}
+	return binaryRMAP(b)
+}
+
+func binaryRMAP(b []byte) (map[int]float64, error) {
 	buf := bytes.NewReader(b)
 	type Dat struct {
 		I int32
@@ -367,50 +404,91 @@ func ReadBinaryRMAP(filepath string) (map[int]float64, error) {
 
 // WriteBinary general binary writer
 func WriteBinary(filepath string, data ...interface{}) error {
-	buf := new(bytes.Buffer)
-	for _, v := range data {
-		if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
-			return fmt.Errorf("mmio.WriteBinary failed: %v", err)
-		}
+	return writeBinary(filepath, false, data...)
+}
+
+// WriteBinaryAtomic is WriteBinary, replacing filepath only once writing has succeeded (see AtomicWrites)
+func WriteBinaryAtomic(filepath string, data ...interface{}) error {
+	return writeBinary(filepath, true, data...)
+}
+
+func writeBinary(filepath string, atomic bool, data ...interface{}) error {
+	buf, err := binaryBuffer(data...)
+	if err != nil {
+		return err
 	}
-	if err := os.WriteFile(filepath, buf.Bytes(), 0644); err != nil { // see: https://en.wikipedia.org/wiki/File_system_permissions
+	if err := writeFile(filepath, buf.Bytes(), 0644, atomic); err != nil { // see: https://en.wikipedia.org/wiki/File_system_permissions
 		return fmt.Errorf("mmio.WriteBinary failed: %v", err)
 	}
 	return nil
 }
 
-// WriteIMAP general map writer
-func WriteIMAP(filepath string, data map[int]int) error {
+// WriteBinaryTo writes data to w in sequence (little-endian), see WriteBinary
+func WriteBinaryTo(w io.Writer, data ...interface{}) error {
+	buf, err := binaryBuffer(data...)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("mmio.WriteBinaryTo failed: %v", err)
+	}
+	return nil
+}
+
+func binaryBuffer(data ...interface{}) (*bytes.Buffer, error) {
 	buf := new(bytes.Buffer)
-	for k, v := range data {
-		if err := binary.Write(buf, binary.LittleEndian, int32(k)); err != nil {
-			log.Fatalln("WriteBinary failed:", err)
-		}
-		if err := binary.Write(buf, binary.LittleEndian, int32(v)); err != nil {
-			log.Fatalln("WriteBinary failed:", err)
+	for _, v := range data {
+		if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+			return nil, fmt.Errorf("mmio.WriteBinary failed: %v", err)
 		}
 	}
-	if err := os.WriteFile(filepath, buf.Bytes(), 0644); err != nil { // see: https://en.wikipedia.org/wiki/File_system_permissions
+	return buf, nil
+}
+
+// WriteIMAP general map writer
+func WriteIMAP(filepath string, data map[int]int) error {
+	return writeIMAP(filepath, data, false)
+}
+
+// WriteIMAPAtomic is WriteIMAP, replacing filepath only once writing has succeeded (see AtomicWrites)
+func WriteIMAPAtomic(filepath string, data map[int]int) error {
+	return writeIMAP(filepath, data, true)
+}
+
+func writeIMAP(filepath string, data map[int]int, atomic bool) error {
+	if err := writeFile(filepath, imapBuffer(data).Bytes(), 0644, atomic); err != nil { // see: https://en.wikipedia.org/wiki/File_system_permissions
 		return fmt.Errorf(" os.WriteIMAP failed: %v", err)
 	}
 	return nil
 }
 
-// WriteRMAP general map writer
-func WriteRMAP(filepath string, data map[int]float64, append bool) error {
+// WriteIMAPTo writes a map[int]int to w as int32 pairs, see WriteIMAP
+func WriteIMAPTo(w io.Writer, data map[int]int) error {
+	if _, err := w.Write(imapBuffer(data).Bytes()); err != nil {
+		return fmt.Errorf("WriteIMAPTo failed: %v", err)
+	}
+	return nil
+}
+
+func imapBuffer(data map[int]int) *bytes.Buffer {
 	buf := new(bytes.Buffer)
 	for k, v := range data {
 		if err := binary.Write(buf, binary.LittleEndian, int32(k)); err != nil {
 			log.Fatalln("WriteBinary failed:", err)
 		}
-		if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+		if err := binary.Write(buf, binary.LittleEndian, int32(v)); err != nil {
 			log.Fatalln("WriteBinary failed:", err)
 		}
 	}
+	return buf
+}
 
+// WriteRMAP general map writer
+func WriteRMAP(filepath string, data map[int]float64, append bool) error {
+	buf := rmapBuffer(data)
 	if append {
-		// If the file doesn't exist, create it, or append to the file
-		f, err := os.OpenFile(filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		// If the file doesn't exist, create it, or append to the file (locked if LockAppends is set)
+		f, err := openAppend(filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY)
 		if err != nil {
 			return err
 		}
@@ -421,9 +499,30 @@ func WriteRMAP(filepath string, data map[int]float64, append bool) error {
 			return err
 		}
 	} else {
-		if err := os.WriteFile(filepath, buf.Bytes(), 0644); err != nil { // see: https://en.wikipedia.org/wiki/File_system_permissions
+		if err := writeFile(filepath, buf.Bytes(), 0644, false); err != nil { // see: https://en.wikipedia.org/wiki/File_system_permissions
 			return fmt.Errorf(" os.WriteRMAP failed: %v", err)
 		}
 	}
 	return nil
 }
+
+// WriteRMAPTo writes a map[int]float64 to w as (int32, float64) records, see WriteRMAP
+func WriteRMAPTo(w io.Writer, data map[int]float64) error {
+	if _, err := w.Write(rmapBuffer(data).Bytes()); err != nil {
+		return fmt.Errorf("WriteRMAPTo failed: %v", err)
+	}
+	return nil
+}
+
+func rmapBuffer(data map[int]float64) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	for k, v := range data {
+		if err := binary.Write(buf, binary.LittleEndian, int32(k)); err != nil {
+			log.Fatalln("WriteBinary failed:", err)
+		}
+		if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+			log.Fatalln("WriteBinary failed:", err)
+		}
+	}
+	return buf
+}
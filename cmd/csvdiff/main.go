@@ -0,0 +1,49 @@
+// csvdiff compares two csv files (with a single header line) cell by cell.
+//
+// usage: csvdiff [-key col1,col2] [-abs 1e-9] [-rel 1e-6] [-ignore col] [-max 50] a.csv b.csv
+//
+// The exit status is 0 when the files match within tolerance, 1 when they differ and 2 on error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/maseology/mmio"
+)
+
+func main() {
+	key := flag.String("key", "", "comma-delimited key columns used to align rows (default: row order)")
+	abs := flag.Float64("abs", 0., "absolute tolerance for numeric cells")
+	rel := flag.Float64("rel", 0., "relative tolerance for numeric cells")
+	ignore := flag.String("ignore", "", "comma-delimited columns to skip")
+	maxCells := flag.Int("max", 50, "maximum number of changed cells to list (0: all)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: csvdiff [options] a.csv b.csv\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opt := mmio.DiffOptions{AbsTol: *abs, RelTol: *rel}
+	if len(*key) > 0 {
+		opt.Key = strings.Split(*key, ",")
+	}
+	if len(*ignore) > 0 {
+		opt.Ignore = strings.Split(*ignore, ",")
+	}
+	d, err := mmio.DiffCSV(flag.Arg(0), flag.Arg(1), opt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "csvdiff: %v\n", err)
+		os.Exit(2)
+	}
+	d.Write(os.Stdout, *maxCells)
+	if !d.Equal() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,255 @@
+package mmio
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// OverwritePolicy decides what CopyDir and MoveDir do with files already in the destination
+type OverwritePolicy int
+
+const (
+	OverwriteNever   OverwritePolicy = iota // existing files are left as they are
+	OverwriteAlways                         // existing files are replaced
+	OverwriteIfNewer                        // existing files are replaced when the source is more recently modified
+)
+
+// CopyOptions filters and controls CopyDir and MoveDir. Patterns use filepath.Match syntax and
+// are tested against both the slash-separated path relative to the source and the base name.
+type CopyOptions struct {
+	Include   []string // files to copy (all files if empty)
+	Exclude   []string // files and directories to skip; an excluded directory is skipped entirely
+	Overwrite OverwritePolicy
+}
+
+func (o CopyOptions) match(patterns []string, rel string) bool {
+	base := filepath.Base(rel)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// replace returns true when dst is absent or may be overwritten by src according to the policy
+func (o CopyOptions) replace(src fs.FileInfo, dst string) (bool, error) {
+	di, err := os.Lstat(dst)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if di.IsDir() {
+		return false, fmt.Errorf("%s is a directory", dst)
+	}
+	switch o.Overwrite {
+	case OverwriteAlways:
+		return true, nil
+	case OverwriteIfNewer:
+		return src.ModTime().After(di.ModTime()), nil
+	}
+	return false, nil
+}
+
+// CopyDir recursively copies the contents of src into dst, creating dst as needed; dst may not
+// be src or lie within it. File and directory mode bits and modification times are preserved;
+// symbolic links are recreated.
+func CopyDir(src, dst string, opt CopyOptions) error {
+	if err := checkDisjoint("CopyDir", src, dst); err != nil {
+		return err
+	}
+	return transferDir("CopyDir", src, dst, opt, false)
+}
+
+// MoveDir recursively moves the contents of src into dst. When there are no filters and dst
+// does not exist, src is renamed in one step. Otherwise files are renamed one by one, falling
+// back to copy and delete across devices; files left behind (filtered out, or not overwritten)
+// remain in src, and emptied source directories are removed. As with CopyDir, dst may not lie within src.
+func MoveDir(src, dst string, opt CopyOptions) error {
+	if err := checkDisjoint("MoveDir", src, dst); err != nil {
+		return err
+	}
+	if len(opt.Include) == 0 && len(opt.Exclude) == 0 {
+		if _, err := os.Lstat(dst); os.IsNotExist(err) {
+			if err := os.MkdirAll(filepath.Dir(filepath.Clean(dst)), 0755); err != nil {
+				return pathError("MoveDir", dst, err)
+			}
+			err := os.Rename(src, dst)
+			if err == nil {
+				return nil
+			} else if !crossDevice(err) {
+				return pathError("MoveDir", src, err)
+			}
+		}
+	}
+	return transferDir("MoveDir", src, dst, opt, true)
+}
+
+// checkDisjoint returns an error if dst is src or lies within it, which would have the walk
+// descend into its own output. Symbolic links in the existing part of both paths are resolved.
+func checkDisjoint(op, src, dst string) error {
+	rel, err := filepath.Rel(realPath(src), realPath(dst))
+	if err != nil {
+		return nil // e.g., different volumes
+	}
+	if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+		return pathError(op, dst, fmt.Errorf("destination is within the source directory %s", src))
+	}
+	return nil
+}
+
+// realPath returns the absolute form of fp, with the symbolic links of its longest existing prefix resolved
+func realPath(fp string) string {
+	fp, err := filepath.Abs(fp)
+	if err != nil {
+		return filepath.Clean(fp)
+	}
+	var rest []string
+	for p := fp; ; p = filepath.Dir(p) {
+		if r, err := filepath.EvalSymlinks(p); err == nil {
+			return filepath.Join(append([]string{r}, rest...)...)
+		}
+		if filepath.Dir(p) == p {
+			return fp
+		}
+		rest = append([]string{filepath.Base(p)}, rest...)
+	}
+}
+
+func transferDir(op, src, dst string, opt CopyOptions, move bool) error {
+	si, err := os.Stat(src)
+	if err != nil {
+		return pathError(op, src, err)
+	}
+	if !si.IsDir() {
+		return pathError(op, src, syscall.ENOTDIR)
+	}
+
+	type dirTime struct {
+		src, dst string
+		fi       fs.FileInfo
+	}
+	var dirs []dirTime
+	err = filepath.WalkDir(src, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, fp)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && opt.match(opt.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, dirTime{fp, target, fi})
+			return nil
+		}
+		if len(opt.Include) > 0 && !opt.match(opt.Include, rel) {
+			return nil
+		}
+		ok, err := opt.replace(fi, target)
+		if err != nil || !ok {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if move {
+			return moveEntry(fp, target, fi)
+		}
+		return copyEntry(fp, target, fi)
+	})
+	if err != nil {
+		return pathError(op, src, err)
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return pathError(op, dst, err)
+	}
+
+	// restore directory modes and times deepest first, after their contents were written;
+	// directories holding no copied files are not created
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		if move {
+			os.Remove(d.src) // only succeeds once emptied
+		}
+		if _, err := os.Stat(d.dst); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Chmod(d.dst, d.fi.Mode().Perm()); err != nil {
+			return pathError(op, d.dst, err)
+		}
+		if err := os.Chtimes(d.dst, d.fi.ModTime(), d.fi.ModTime()); err != nil {
+			return pathError(op, d.dst, err)
+		}
+	}
+	return nil
+}
+
+// copyEntry copies a regular file or symbolic link, preserving mode bits and modification time
+func copyEntry(src, dst string, fi fs.FileInfo) error {
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		lnk, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.Symlink(lnk, dst)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil // devices, sockets and pipes are not copied
+	}
+	s, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	d, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm()|0200)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(d, s)
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, fi.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, fi.ModTime(), fi.ModTime())
+}
+
+// moveEntry renames src to dst, falling back to copy and delete when they are on different devices
+func moveEntry(src, dst string, fi fs.FileInfo) error {
+	err := os.Rename(src, dst)
+	if err == nil || !crossDevice(err) {
+		return err
+	}
+	if err := copyEntry(src, dst, fi); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
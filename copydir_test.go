@@ -0,0 +1,157 @@
+package mmio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// writeTree creates files (slash-separated path: content) under root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, s := range files {
+		fp := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the regular files under root as slash-separated path: content
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	m := make(map[string]string)
+	filepath.Walk(root, func(fp string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			rel, _ := filepath.Rel(root, fp)
+			b, _ := os.ReadFile(fp)
+			m[filepath.ToSlash(rel)] = string(b)
+		}
+		return nil
+	})
+	return m
+}
+
+func TestCopyDir(t *testing.T) {
+	src := map[string]string{"a.csv": "a", "b.txt": "b", "sub/c.csv": "c", "tmp/d.csv": "d"}
+	tests := []struct {
+		name     string
+		opt      CopyOptions
+		existing map[string]string // in dst before copying
+		want     map[string]string
+	}{
+		{"all", CopyOptions{}, nil, src},
+		{"include", CopyOptions{Include: []string{"*.csv"}}, nil,
+			map[string]string{"a.csv": "a", "sub/c.csv": "c", "tmp/d.csv": "d"}},
+		{"exclude dir", CopyOptions{Exclude: []string{"tmp"}}, nil,
+			map[string]string{"a.csv": "a", "b.txt": "b", "sub/c.csv": "c"}},
+		{"overwrite never", CopyOptions{Overwrite: OverwriteNever}, map[string]string{"a.csv": "old"},
+			map[string]string{"a.csv": "old", "b.txt": "b", "sub/c.csv": "c", "tmp/d.csv": "d"}},
+		{"overwrite always", CopyOptions{Overwrite: OverwriteAlways}, map[string]string{"a.csv": "old"}, src},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := t.TempDir()
+			s, dst := filepath.Join(d, "src"), filepath.Join(d, "dst")
+			writeTree(t, s, src)
+			writeTree(t, dst, tt.existing)
+			if err := CopyDir(s, dst, tt.opt); err != nil {
+				t.Fatal(err)
+			}
+			if got := readTree(t, dst); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("copied %v, want %v", got, tt.want)
+			}
+			if got := readTree(t, s); !reflect.DeepEqual(got, src) {
+				t.Errorf("source changed to %v", got)
+			}
+		})
+	}
+}
+
+func TestCopyDirPreservesTimes(t *testing.T) {
+	d := t.TempDir()
+	s, dst := filepath.Join(d, "src"), filepath.Join(d, "dst")
+	writeTree(t, s, map[string]string{"sub/a": "a"})
+	mt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, fp := range []string{filepath.Join(s, "sub", "a"), filepath.Join(s, "sub")} {
+		os.Chtimes(fp, mt, mt)
+	}
+	if err := CopyDir(s, dst, CopyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, fp := range []string{filepath.Join(dst, "sub", "a"), filepath.Join(dst, "sub")} {
+		if fi, err := os.Stat(fp); err != nil || !fi.ModTime().Equal(mt) {
+			t.Errorf("%s: modification time not preserved (%v)", fp, err)
+		}
+	}
+}
+
+func TestCopyDirWithinSource(t *testing.T) {
+	d := t.TempDir()
+	s := filepath.Join(d, "src")
+	writeTree(t, s, map[string]string{"a": "a"})
+	tests := []struct {
+		dst     string
+		wantErr bool
+	}{
+		{s, true},
+		{filepath.Join(s, "out"), true},
+		{filepath.Join(s, "sub", "..", "out"), true},
+		{filepath.Join(d, "src-copy"), false},
+		{filepath.Join(d, "..src"), false},
+	}
+	for _, tt := range tests {
+		err := CopyDir(s, tt.dst, CopyOptions{})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CopyDir to %s: %v", tt.dst, err)
+		}
+		if !tt.wantErr {
+			continue
+		}
+		if err := MoveDir(s, tt.dst, CopyOptions{}); err == nil {
+			t.Errorf("MoveDir to %s: no error", tt.dst)
+		}
+	}
+}
+
+func TestMoveDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		opt      CopyOptions
+		wantDst  []string
+		wantLeft []string
+	}{
+		{"rename", CopyOptions{}, []string{"a.csv", "sub/b.txt"}, nil},
+		{"filtered", CopyOptions{Include: []string{"*.csv"}}, []string{"a.csv"}, []string{"sub/b.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := t.TempDir()
+			s, dst := filepath.Join(d, "src"), filepath.Join(d, "out", "dst")
+			writeTree(t, s, map[string]string{"a.csv": "a", "sub/b.txt": "b"})
+			if err := MoveDir(s, dst, tt.opt); err != nil {
+				t.Fatal(err)
+			}
+			keys := func(m map[string]string) []string {
+				var ks []string
+				for k := range m {
+					ks = append(ks, k)
+				}
+				sort.Strings(ks)
+				return ks
+			}
+			if got := keys(readTree(t, dst)); !reflect.DeepEqual(got, tt.wantDst) {
+				t.Errorf("moved %v, want %v", got, tt.wantDst)
+			}
+			if got := keys(readTree(t, s)); !reflect.DeepEqual(got, tt.wantLeft) {
+				t.Errorf("left %v, want %v", got, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !plan9
+
+package mmio
+
+import (
+	"errors"
+	"runtime"
+	"syscall"
+)
+
+// crossDevice returns true if a rename failed because source and destination are on different devices
+func crossDevice(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.EXDEV || (runtime.GOOS == "windows" && errno == 17) // ERROR_NOT_SAME_DEVICE
+}
@@ -0,0 +1,6 @@
+package mmio
+
+// crossDevice returns false: Plan 9 renames only within a directory, and its errors carry no EXDEV code
+func crossDevice(err error) bool {
+	return false
+}
@@ -0,0 +1,117 @@
+package mmio
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// FloatFormat controls how floating point values are written by CSVwriter.
+// Fmt and Prec follow strconv.FormatFloat; the zero value keeps fmt.Sprint behaviour.
+type FloatFormat struct {
+	Fmt  byte // 'f', 'e', 'g'
+	Prec int  // number of decimals ('f', 'e') or significant digits ('g'); -1 for shortest
+}
+
+// FixedDecimals formats floats with n digits after the decimal point
+func FixedDecimals(n int) FloatFormat { return FloatFormat{Fmt: 'f', Prec: n} }
+
+// SignificantDigits formats floats with n significant digits
+func SignificantDigits(n int) FloatFormat { return FloatFormat{Fmt: 'g', Prec: n} }
+
+// ShortestRoundTrip formats floats with the fewest digits needed to read back the exact value
+func ShortestRoundTrip() FloatFormat { return FloatFormat{Fmt: 'g', Prec: -1} }
+
+// SetFloatFormat sets the default float format for all columns
+func (w *CSVwriter) SetFloatFormat(ff FloatFormat) {
+	w.floatFmt = ff
+}
+
+// SetColumnFormat overrides the float format of a given (0-based) output column
+func (w *CSVwriter) SetColumnFormat(col int, ff FloatFormat) {
+	if w.colFmt == nil {
+		w.colFmt = make(map[int]FloatFormat)
+	}
+	w.colFmt[col] = ff
+}
+
+// SetTimeLayout sets the layout used to write time.Time values (see time.Format)
+func (w *CSVwriter) SetTimeLayout(layout string) {
+	w.timeLayout = layout
+}
+
+// SetTimeLocation converts time.Time values to loc before they are written (nil: as given)
+func (w *CSVwriter) SetTimeLocation(loc *time.Location) {
+	w.timeLoc = loc
+}
+
+// SetNaN sets the string written in place of NaN (default "NaN")
+func (w *CSVwriter) SetNaN(s string) {
+	w.nan = &s
+}
+
+// SetInf sets the strings written in place of +Inf and -Inf (defaults "+Inf", "-Inf")
+func (w *CSVwriter) SetInf(pos, neg string) {
+	w.posInf, w.negInf = &pos, &neg
+}
+
+func (w *CSVwriter) formatFloat(col int, v float64, bitSize int) string {
+	switch {
+	case math.IsNaN(v):
+		if w.nan != nil {
+			return *w.nan
+		}
+	case math.IsInf(v, 1):
+		if w.posInf != nil {
+			return *w.posInf
+		}
+	case math.IsInf(v, -1):
+		if w.negInf != nil {
+			return *w.negInf
+		}
+	}
+	ff := w.floatFmt
+	if cf, ok := w.colFmt[col]; ok {
+		ff = cf
+	}
+	if ff.Fmt == 0 {
+		if bitSize == 32 {
+			return fmt.Sprint(float32(v))
+		}
+		return fmt.Sprint(v)
+	}
+	return strconv.FormatFloat(v, ff.Fmt, ff.Prec, bitSize)
+}
+
+func (w *CSVwriter) formatTime(t time.Time) string {
+	if w.timeLoc != nil {
+		t = t.In(w.timeLoc)
+	}
+	if len(w.timeLayout) == 0 {
+		return fmt.Sprint(t)
+	}
+	return t.Format(w.timeLayout)
+}
+
+// formatValue converts a scalar value to its csv cell string
+func (w *CSVwriter) formatValue(col int, v interface{}) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case float64:
+		return w.formatFloat(col, vv, 64)
+	case float32:
+		return w.formatFloat(col, float64(vv), 32)
+	case int:
+		return strconv.Itoa(vv)
+	case int32:
+		return strconv.FormatInt(int64(vv), 10)
+	case int64:
+		return strconv.FormatInt(vv, 10)
+	case time.Time:
+		return w.formatTime(vv)
+	default:
+		return fmt.Sprint(v)
+	}
+}
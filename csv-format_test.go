@@ -0,0 +1,73 @@
+package mmio
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVwriterFormats(t *testing.T) {
+	t0 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		setup func(w *CSVwriter)
+		line  []interface{}
+		want  string
+	}{
+		{"default", func(w *CSVwriter) {}, []interface{}{1.5, float32(0.1), 2, int64(-3), "a"}, "1.5,0.1,2,-3,a"},
+		{"slices", func(w *CSVwriter) {}, []interface{}{[]int32{1, 2}, []string{"x"}, []float64{0.25}}, "1,2,x,0.25"},
+		{"fixed decimals", func(w *CSVwriter) { w.SetFloatFormat(FixedDecimals(2)) }, []interface{}{1.005, []float64{2, 1e6}}, "1.00,2.00,1000000.00"},
+		{"significant digits", func(w *CSVwriter) { w.SetFloatFormat(SignificantDigits(3)) }, []interface{}{123456., 0.000123456}, "1.23e+05,0.000123"},
+		{"column format", func(w *CSVwriter) {
+			w.SetFloatFormat(FixedDecimals(1))
+			w.SetColumnFormat(1, FloatFormat{Fmt: 'e', Prec: 2})
+		}, []interface{}{"id", 1234.5, 2.}, "id,1.23e+03,2.0"},
+		{"float32 shortest", func(w *CSVwriter) { w.SetFloatFormat(ShortestRoundTrip()) }, []interface{}{float32(0.1), 0.1}, "0.1,0.1"},
+		{"NaN and Inf", func(w *CSVwriter) {}, []interface{}{math.NaN(), math.Inf(1), math.Inf(-1)}, "NaN,+Inf,-Inf"},
+		{"NaN and Inf strings", func(w *CSVwriter) {
+			w.SetNaN("")
+			w.SetInf("inf", "-inf")
+		}, []interface{}{[]float64{math.NaN(), math.Inf(1), math.Inf(-1)}}, ",inf,-inf"},
+		{"time layout", func(w *CSVwriter) { w.SetTimeLayout("2006-01-02") }, []interface{}{t0, []time.Time{t0}}, "2020-01-02,2020-01-02"},
+		{"quoting", func(w *CSVwriter) {}, []interface{}{"a,b", `c"d`}, `"a,b","c""d"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewCSVwriterTo(&buf)
+			tt.setup(w)
+			if err := w.WriteLine(tt.line...); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.CloseE(); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.want {
+				t.Errorf("wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVwriterShortestRoundTrip(t *testing.T) {
+	vs := []float64{math.Pi, 1. / 3, 1e-300, -2.5e17, 0.1 + 0.2}
+	var buf bytes.Buffer
+	w := NewCSVwriterTo(&buf)
+	w.SetFloatFormat(ShortestRoundTrip())
+	w.WriteHead("a,b,c,d,e")
+	w.WriteLine(vs)
+	if err := w.CloseE(); err != nil {
+		t.Fatal(err)
+	}
+	d, err := ReadCSVFrom(&buf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vs {
+		if d[0][i] != v {
+			t.Errorf("column %d read back as %v, want %v", i, d[0][i], v)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package mmio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+var csvChunkSize int64 = 8 << 20 // target bytes per parsed chunk (a variable for tests)
+
+// ReadCSVParallel is a parallel version of ReadCSV (must be completely numeric) for very large files.
+// The file is split into byte ranges at record boundaries that are parsed concurrently by nworkers
+// goroutines (nworkers < 1 uses GOMAXPROCS); rows are returned in file order.
+func ReadCSVParallel(filepath string, nHeaderLines, nworkers int) ([][]float64, error) {
+	var fout [][]float64
+	err := ReadCSVChunks(filepath, nHeaderLines, nworkers, func(rows [][]float64) error {
+		fout = append(fout, rows...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fout, nil
+}
+
+// ReadCSVChunks parses a numeric csv in parallel as ReadCSVParallel, but streams the parsed chunks
+// to fn, in file order, instead of building the whole matrix. Returning an error from fn stops the read.
+func ReadCSVChunks(filepath string, nHeaderLines, nworkers int, fn func(rows [][]float64) error) error {
+	f, err := openRead(filepath)
+	if err != nil {
+		return fmt.Errorf("ReadCSVChunks failed: %v", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("ReadCSVChunks failed: %v", err)
+	}
+	return readCSVChunks(f, fi.Size(), filepath, nHeaderLines, nworkers, fn)
+}
+
+// ReadCSVChunksReaderAt parses a numeric csv of the given size from ra, as ReadCSVChunks
+// (parallel parsing needs random access, a plain io.Reader can be read with ReadCSVFrom)
+func ReadCSVChunksReaderAt(ra io.ReaderAt, size int64, nHeaderLines, nworkers int, fn func(rows [][]float64) error) error {
+	return readCSVChunks(ra, size, "input", nHeaderLines, nworkers, fn)
+}
+
+func readCSVChunks(f io.ReaderAt, size int64, name string, nHeaderLines, nworkers int, fn func(rows [][]float64) error) error {
+	start, err := csvDataOffset(f, nHeaderLines)
+	if err != nil {
+		return fmt.Errorf("ReadCSVChunks failed reading header: %v", err)
+	}
+	bnds, err := csvChunkBounds(f, start, size, csvChunkSize)
+	if err != nil {
+		return fmt.Errorf("ReadCSVChunks failed: %v", err)
+	}
+	nc := len(bnds) - 1
+	if nworkers < 1 {
+		nworkers = runtime.GOMAXPROCS(0)
+	}
+
+	type chunk struct {
+		i    int
+		rows [][]float64
+		err  error
+	}
+	jobs, res, done := make(chan int), make(chan chunk, nworkers), make(chan struct{})
+	sem := make(chan struct{}, 2*nworkers) // bounds the number of parsed chunks held in memory
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait() // on early returns, no worker may still be reading f once the caller closes it
+	}()
+
+	go func() {
+		defer close(jobs)
+		for i := 0; i < nc; i++ {
+			select {
+			case sem <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for k := 0; k < nworkers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rows, err := parseCsvChunk(f, bnds[i], bnds[i+1])
+				select {
+				case res <- chunk{i, rows, err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(res)
+	}()
+
+	pending, next, ncol := make(map[int]chunk), 0, -1
+	for c := range res {
+		pending[c.i] = c
+		for {
+			p, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if p.err != nil {
+				return fmt.Errorf("ReadCSVChunks failed in %s (chunk %d): %v", name, next, p.err)
+			}
+			if len(p.rows) > 0 {
+				if ncol < 0 {
+					ncol = len(p.rows[0])
+				} else if len(p.rows[0]) != ncol {
+					return fmt.Errorf("ReadCSVChunks failed in %s (chunk %d): wrong number of fields", name, next)
+				}
+				if err := fn(p.rows); err != nil {
+					return err
+				}
+			}
+			next++
+			<-sem
+		}
+	}
+	return nil
+}
+
+// csvDataOffset returns the byte offset of the first record following the header lines
+func csvDataOffset(f io.ReaderAt, nHeaderLines int) (int64, error) {
+	r := csv.NewReader(io.NewSectionReader(f, 0, 1<<62))
+	r.FieldsPerRecord = -1
+	for l := 0; l < nHeaderLines; l++ {
+		if _, err := r.Read(); err != nil {
+			return 0, err
+		}
+	}
+	return r.InputOffset(), nil
+}
+
+// csvChunkBounds scans from start to size and returns chunk boundaries, each placed just after
+// the first newline lying outside of a quoted field once the target chunk size is reached.
+func csvChunkBounds(f io.ReaderAt, start, size, chunkSize int64) ([]int64, error) {
+	b := []int64{start}
+	if start >= size {
+		return b, nil
+	}
+	br := bufio.NewReaderSize(io.NewSectionReader(f, start, size-start), 1<<20)
+	inq, pos, next := false, start, start+chunkSize
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		pos++
+		switch c {
+		case '"':
+			inq = !inq
+		case '\n':
+			if !inq && pos >= next {
+				b = append(b, pos)
+				next = pos + chunkSize
+			}
+		}
+	}
+	if b[len(b)-1] < size {
+		b = append(b, size)
+	}
+	return b, nil
+}
+
+func parseCsvChunk(f io.ReaderAt, from, to int64) ([][]float64, error) {
+	r := csv.NewReader(io.NewSectionReader(f, from, to-from))
+	r.ReuseRecord = true
+	var fout [][]float64
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		f1 := make([]float64, len(rec))
+		for i, c := range rec {
+			if f1[i], err = strconv.ParseFloat(c, 64); err != nil {
+				return nil, fmt.Errorf("rec[%v]: %v; error: %v", i, rec, err)
+			}
+		}
+		fout = append(fout, f1)
+	}
+	return fout, nil
+}
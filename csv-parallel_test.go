@@ -0,0 +1,126 @@
+package mmio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCsvChunkBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		start     int64
+		chunkSize int64
+		want      []int64
+	}{
+		{"one chunk", "1,2\n3,4\n", 0, 100, []int64{0, 8}},
+		{"each line", "1,2\n3,4\n5,6\n", 0, 1, []int64{0, 4, 8, 12}},
+		{"two lines", "1,2\n3,4\n5,6\n", 0, 5, []int64{0, 8, 12}},
+		{"after header", "a,b\n1,2\n3,4\n", 4, 1, []int64{4, 8, 12}},
+		{"no final newline", "1,2\n3,4", 0, 1, []int64{0, 4, 7}},
+		{"quoted newline", "1,\"x\ny\"\n3,4\n", 0, 1, []int64{0, 8, 12}},
+		{"empty", "a,b\n", 4, 1, []int64{4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := csvChunkBounds(strings.NewReader(tt.in), tt.start, int64(len(tt.in)), tt.chunkSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bounds %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVChunks(t *testing.T) {
+	defer func(n int64) { csvChunkSize = n }(csvChunkSize)
+	csvChunkSize = 64
+
+	var sb strings.Builder
+	sb.WriteString("a,b,c\n")
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&sb, "%d,%g,%g\n", i, float64(i)/7, -float64(i)*1e3)
+	}
+	in := sb.String()
+	want, err := ReadCSVFrom(strings.NewReader(in), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, nworkers := range []int{1, 3, 0} {
+		var got [][]float64
+		nchunks := 0
+		err := ReadCSVChunksReaderAt(strings.NewReader(in), int64(len(in)), 1, nworkers, func(rows [][]float64) error {
+			got = append(got, rows...)
+			nchunks++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nchunks < 2 || !reflect.DeepEqual(got, want) {
+			t.Errorf("nworkers %d: %d rows in %d chunks differ from ReadCSVFrom", nworkers, len(got), nchunks)
+		}
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = ReadCSVChunksReaderAt(strings.NewReader(in), int64(len(in)), 1, 2, func(rows [][]float64) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("fn error: got %v after %d chunks, want %v after 1", err, n, stop)
+	}
+
+	bad := "a,b\n1,2\n3,x\n"
+	if err := ReadCSVChunksReaderAt(strings.NewReader(bad), int64(len(bad)), 1, 2, func([][]float64) error { return nil }); err == nil {
+		t.Error("non-numeric cell: no error")
+	}
+}
+
+// closingReaderAt fails reads made after Close, as an *os.File would
+type closingReaderAt struct {
+	r      *strings.Reader
+	closed atomic.Bool
+	late   atomic.Int32
+}
+
+func (c *closingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	time.Sleep(time.Millisecond) // keep workers busy while the consumer fails
+	if c.closed.Load() {
+		c.late.Add(1)
+		return 0, os.ErrClosed
+	}
+	return c.r.ReadAt(p, off)
+}
+
+func TestReadCSVChunksWaitsForWorkers(t *testing.T) {
+	defer func(n int64) { csvChunkSize = n }(csvChunkSize)
+	csvChunkSize = 16
+
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, "%d,%d\n", i, 2*i)
+	}
+	fail := errors.New("fail")
+	for _, nworkers := range []int{1, 4, 16} {
+		ra := &closingReaderAt{r: strings.NewReader(sb.String())}
+		err := ReadCSVChunksReaderAt(ra, ra.r.Size(), 0, nworkers, func([][]float64) error { return fail })
+		ra.closed.Store(true)
+		if err != fail {
+			t.Errorf("nworkers %d: error %v, want %v", nworkers, err, fail)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if n := ra.late.Load(); n > 0 {
+			t.Errorf("nworkers %d: %d reads after return", nworkers, n)
+		}
+	}
+}
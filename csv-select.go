@@ -0,0 +1,172 @@
+package mmio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CsvSelect chooses the columns and rows to keep when reading a csv
+type CsvSelect struct {
+	Names   []string              // column names, matched against the first header line
+	Indices []int                 // 0-based column indices, used when Names is empty (all columns if both are empty)
+	Where   func(row CsvRow) bool // optional row predicate, evaluated before any cell is kept
+}
+
+// CsvRow gives a row predicate access to the raw cells of the current record
+type CsvRow struct {
+	rec []string
+	ix  map[string]int
+}
+
+// Get returns the named cell ("" if the column is missing or there is no header)
+func (r CsvRow) Get(name string) string {
+	if j, ok := r.ix[name]; ok && j < len(r.rec) {
+		return r.rec[j]
+	}
+	return ""
+}
+
+// At returns the cell at a 0-based column index ("" if out of range)
+func (r CsvRow) At(j int) string {
+	if j >= 0 && j < len(r.rec) {
+		return r.rec[j]
+	}
+	return ""
+}
+
+// LoadCsvSelect reads only the selected columns of the rows passing sel.Where, as LoadCsvArray
+func LoadCsvSelect(fp string, nHeaderLines int, sel CsvSelect) ([][]string, error) {
+	return readPath("LoadCsvSelect", fp, func(r io.Reader) ([][]string, error) { return loadCsvSelect(r, nHeaderLines, sel) })
+}
+
+// LoadCsvSelectFrom reads the selected columns and rows of the csv read from r, see LoadCsvSelect
+func LoadCsvSelectFrom(r io.Reader, nHeaderLines int, sel CsvSelect) ([][]string, error) {
+	a, err := loadCsvSelect(r, nHeaderLines, sel)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCsvSelect: %v", err)
+	}
+	return a, nil
+}
+
+func loadCsvSelect(r io.Reader, nHeaderLines int, sel CsvSelect) ([][]string, error) {
+	var a [][]string
+	err := scanCsvSelect(r, nHeaderLines, sel, func(rec []string, jj []int) error {
+		s := make([]string, len(jj))
+		for k, j := range jj {
+			s[k] = rec[j]
+		}
+		a = append(a, s)
+		return nil
+	})
+	return a, err
+}
+
+// ReadCsvSelect reads and parses only the selected columns of the rows passing sel.Where, as ReadCSV.
+// Blank and "NA" cells are returned as NaN.
+func ReadCsvSelect(fp string, nHeaderLines int, sel CsvSelect) ([][]float64, error) {
+	return readPath("ReadCsvSelect", fp, func(r io.Reader) ([][]float64, error) { return readCsvSelect(r, nHeaderLines, sel) })
+}
+
+// ReadCsvSelectFrom reads and parses the selected columns and rows of the csv read from r, see ReadCsvSelect
+func ReadCsvSelectFrom(r io.Reader, nHeaderLines int, sel CsvSelect) ([][]float64, error) {
+	a, err := readCsvSelect(r, nHeaderLines, sel)
+	if err != nil {
+		return nil, fmt.Errorf("ReadCsvSelect: %v", err)
+	}
+	return a, nil
+}
+
+func readCsvSelect(r io.Reader, nHeaderLines int, sel CsvSelect) ([][]float64, error) {
+	var a [][]float64
+	err := scanCsvSelect(r, nHeaderLines, sel, func(rec []string, jj []int) error {
+		f := make([]float64, len(jj))
+		for k, j := range jj {
+			c := strings.TrimSpace(rec[j])
+			if len(c) == 0 || c == "NA" {
+				f[k] = math.NaN()
+				continue
+			}
+			v, err := strconv.ParseFloat(c, 64)
+			if err != nil {
+				return fmt.Errorf("rec[%v]: %v", j, err)
+			}
+			f[k] = v
+		}
+		a = append(a, f)
+		return nil
+	})
+	return a, err
+}
+
+func scanCsvSelect(rd io.Reader, nHeaderLines int, sel CsvSelect, keep func(rec []string, jj []int) error) error {
+	r := csv.NewReader(rd)
+	r.ReuseRecord = true
+	r.FieldsPerRecord = -1
+	var ix map[string]int
+	for l := 0; l < nHeaderLines; l++ {
+		rec, err := r.Read()
+		if err != nil {
+			return fmt.Errorf("cannot read header: %v", err)
+		}
+		if l == 0 {
+			ix = make(map[string]int, len(rec))
+			for j, h := range rec {
+				ix[strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF"))] = j
+			}
+		}
+	}
+
+	jj := sel.Indices
+	if len(sel.Names) > 0 {
+		if ix == nil {
+			return fmt.Errorf("column names given without a header line")
+		}
+		jj = make([]int, len(sel.Names))
+		for k, n := range sel.Names {
+			j, ok := ix[n]
+			if !ok {
+				return fmt.Errorf("column %s not found", n)
+			}
+			jj[k] = j
+		}
+	}
+	all, jmax := len(jj) == 0, -1
+	for _, j := range jj {
+		if j < 0 {
+			return fmt.Errorf("invalid column index %d", j)
+		}
+		if j > jmax {
+			jmax = j
+		}
+	}
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if sel.Where != nil && !sel.Where(CsvRow{rec, ix}) {
+			continue
+		}
+		if all {
+			for len(jj) < len(rec) {
+				jj = append(jj, len(jj))
+			}
+			jj, jmax = jj[:len(rec)], len(rec)-1
+		}
+		if jmax >= len(rec) {
+			line, _ := r.FieldPos(0)
+			return fmt.Errorf("line %d: %d fields, column %d requested", line, len(rec), jmax+1)
+		}
+		if err := keep(rec, jj); err != nil {
+			line, _ := r.FieldPos(0)
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package mmio
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCsvSelect(t *testing.T) {
+	const in = "id,name,q,h\n1,a,1.5,10\n2,b,NA,20\n3,c,3,\n"
+	tests := []struct {
+		name    string
+		sel     CsvSelect
+		want    [][]string
+		wantErr bool
+	}{
+		{"all", CsvSelect{}, [][]string{{"1", "a", "1.5", "10"}, {"2", "b", "NA", "20"}, {"3", "c", "3", ""}}, false},
+		{"names, reordered", CsvSelect{Names: []string{"h", "id"}}, [][]string{{"10", "1"}, {"20", "2"}, {"", "3"}}, false},
+		{"indices", CsvSelect{Indices: []int{1}}, [][]string{{"a"}, {"b"}, {"c"}}, false},
+		{"where", CsvSelect{Names: []string{"id"}, Where: func(r CsvRow) bool { return r.Get("name") != "b" && r.At(0) != "" }},
+			[][]string{{"1"}, {"3"}}, false},
+		{"missing name", CsvSelect{Names: []string{"x"}}, nil, true},
+		{"index out of range", CsvSelect{Indices: []int{4}}, nil, true},
+		{"negative index", CsvSelect{Indices: []int{-1}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadCsvSelectFrom(strings.NewReader(in), 1, tt.sel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadCsvSelectFrom(strings.NewReader(in), 0, CsvSelect{Names: []string{"id"}}); err == nil {
+		t.Error("names without a header line: no error")
+	}
+}
+
+func TestReadCsvSelect(t *testing.T) {
+	const in = "id,name,q,h\n1,a,1.5,10\n2,b,NA,20\n3,c,3,\n"
+	got, err := ReadCsvSelectFrom(strings.NewReader(in), 1, CsvSelect{Names: []string{"q", "h"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]float64{{1.5, 10}, {math.NaN(), 20}, {3, math.NaN()}}
+	for i := range want {
+		for j := range want[i] {
+			if g, w := got[i][j], want[i][j]; g != w && !(math.IsNaN(g) && math.IsNaN(w)) {
+				t.Errorf("row %d: %v, want %v", i, got[i], want[i])
+				break
+			}
+		}
+	}
+	_, err = ReadCsvSelectFrom(strings.NewReader(in), 1, CsvSelect{Names: []string{"name"}})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("text column: error %v, want one naming line 2", err)
+	}
+}
@@ -1,29 +1,30 @@
 package mmio
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io"
 	"math"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // ReadCsvDateFloat reads temporal csv file "date,value,flag,..."
 func ReadCsvDateFloat(csvfp string) (map[int64]float64, error) {
-	f, err := os.Open(csvfp)
-	if err != nil {
-		fmt.Printf("ReadCsvDateFloat failed: %v\n", err)
-		return nil, fmt.Errorf("ReadCsvDateFloat failed: %v", err)
-	}
-	defer f.Close()
+	return readPath("ReadCsvDateFloat", csvfp, ReadCsvDateFloatFrom)
+}
 
-	recs := LoadCSV(io.Reader(f), 1)
-	o := make(map[int64]float64, len(recs)-1)
-	for rec := range recs {
-		t, err := dateParse(rec[0])
+// ReadCsvDateFloatFrom reads temporal csv "date,value,flag,..." from r
+func ReadCsvDateFloatFrom(r io.Reader) (map[int64]float64, error) {
+	recs := streamCSV(r, 1)
+	defer recs.Close()
+	o := make(map[int64]float64)
+	dp := DefaultDateParser.Clone()
+	for rec := range recs.C {
+		t, err := dp.Parse(rec[0])
 		if err != nil {
-			return nil, fmt.Errorf("date parse error in %s: %v", csvfp, err)
+			return nil, fmt.Errorf("date parse error: %v", err)
 		}
 		v, err := strconv.ParseFloat(rec[1], 64)
 		if err != nil {
@@ -31,25 +32,28 @@ func ReadCsvDateFloat(csvfp string) (map[int64]float64, error) {
 		}
 		o[t.Unix()] = v
 	}
+	if err := recs.Close(); err != nil {
+		return nil, err
+	}
 	return o, nil
 }
 
 // ReadCsvDateFloat reads temporal csv file "date,value,flag,..."
 func ReadCsvDateFloats(csvfp string) (map[time.Time][]float64, error) {
-	f, err := os.Open(csvfp)
-	if err != nil {
-		fmt.Printf("ReadCsvDateFloats failed: %v\n", err)
-		return nil, fmt.Errorf("ReadCsvDateFloats failed: %v", err)
-	}
-	defer f.Close()
+	return readPath("ReadCsvDateFloats", csvfp, ReadCsvDateFloatsFrom)
+}
 
-	recs := LoadCSV(io.Reader(f), 1)
+// ReadCsvDateFloatsFrom reads temporal csv "date,value(s)" from r
+func ReadCsvDateFloatsFrom(r io.Reader) (map[time.Time][]float64, error) {
+	recs := streamCSV(r, 1)
+	defer recs.Close()
 	ncol := -1 // ncolsCSV(io.Reader(f)) - 1
-	o := make(map[time.Time][]float64, len(recs)-1)
-	for rec := range recs {
-		t, err := dateParse(rec[0])
+	o := make(map[time.Time][]float64)
+	dp := DefaultDateParser.Clone()
+	for rec := range recs.C {
+		t, err := dp.Parse(rec[0])
 		if err != nil {
-			return nil, fmt.Errorf("date parse error in %s: %v", csvfp, err)
+			return nil, fmt.Errorf("date parse error: %v", err)
 		}
 		if ncol < 0 {
 			ncol = len(rec) - 1
@@ -67,47 +71,134 @@ func ReadCsvDateFloats(csvfp string) (map[time.Time][]float64, error) {
 		}
 		o[t] = vs
 	}
+	if err := recs.Close(); err != nil {
+		return nil, err
+	}
 	return o, nil
 }
 
-// ReadCsvStringInt reads temporal csv file ith column type "<str>,<int>"
-func ReadCsvStringInt(csvfp string) (map[string]int, error) {
-	f, err := os.Open(csvfp)
+// ReadCsvDateFloatFlag reads temporal csv file "date,value,flag,..." retaining the flag column.
+// Missing values ("" or "NA") are returned as NaN so that their flags are kept.
+func ReadCsvDateFloatFlag(csvfp string) (FlaggedSeries, error) {
+	return readPath("ReadCsvDateFloatFlag", csvfp, ReadCsvDateFloatFlagFrom)
+}
+
+// ReadCsvDateFloatFlagFrom reads temporal csv "date,value,flag,..." from r, see ReadCsvDateFloatFlag
+func ReadCsvDateFloatFlagFrom(r io.Reader) (FlaggedSeries, error) {
+	return readCsvDateFloatFlag(r, nil)
+}
+
+// ReadCsvDateFloatExtra reads temporal csv file "date,value,flag,..." retaining the flag column and
+// the named extra columns, returned in FlaggedValue.Extra
+func ReadCsvDateFloatExtra(csvfp string, extra ...string) (FlaggedSeries, error) {
+	return readPath("ReadCsvDateFloatExtra", csvfp, func(r io.Reader) (FlaggedSeries, error) { return readCsvDateFloatFlag(r, extra) })
+}
+
+// ReadCsvDateFloatExtraFrom reads temporal csv "date,value,flag,..." from r, see ReadCsvDateFloatExtra
+func ReadCsvDateFloatExtraFrom(r io.Reader, extra ...string) (FlaggedSeries, error) {
+	return readCsvDateFloatFlag(r, extra)
+}
+
+func readCsvDateFloatFlag(rc io.Reader, extra []string) (FlaggedSeries, error) {
+	r := csv.NewReader(rc)
+	r.FieldsPerRecord = -1
+	head, err := r.Read()
 	if err != nil {
-		fmt.Printf("ReadCSV failed: %v\n", err)
-		return nil, fmt.Errorf("ReadCSV failed: %v", err)
+		return nil, fmt.Errorf("ReadCsvDateFloatFlag failed to read header: %v", err)
+	}
+	ix := make([]int, len(extra))
+	for k, e := range extra {
+		ix[k] = -1
+		for j, h := range head {
+			if strings.EqualFold(strings.TrimSpace(h), e) {
+				ix[k] = j
+				break
+			}
+		}
+		if ix[k] < 0 {
+			return nil, fmt.Errorf("ReadCsvDateFloatFlag: column %s not found", e)
+		}
 	}
-	defer f.Close()
 
-	recs := LoadCSV(io.Reader(f), 1)
-	o := make(map[string]int, len(recs)-1)
-	for rec := range recs {
+	o, dp := make(FlaggedSeries), DefaultDateParser.Clone()
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("ReadCsvDateFloatFlag failed: %v", err)
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("ReadCsvDateFloatFlag: too few fields: %v", rec)
+		}
+		t, err := dp.Parse(rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("date parse error: %v", err)
+		}
+		fv := FlaggedValue{V: math.NaN()}
+		if s := strings.TrimSpace(rec[1]); len(s) > 0 && s != "NA" {
+			if fv.V, err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("value parse error: %v", err)
+			}
+		}
+		if len(rec) > 2 {
+			fv.Flag = strings.TrimSpace(rec[2])
+		}
+		if len(extra) > 0 {
+			fv.Extra = make(map[string]string, len(extra))
+			for k, e := range extra {
+				if ix[k] < len(rec) {
+					fv.Extra[e] = rec[ix[k]]
+				}
+			}
+		}
+		o[t] = fv
+	}
+	return o, nil
+}
+
+// ReadCsvStringInt reads temporal csv file ith column type "<str>,<int>"
+func ReadCsvStringInt(csvfp string) (map[string]int, error) {
+	return readPath("ReadCsvStringInt", csvfp, ReadCsvStringIntFrom)
+}
+
+// ReadCsvStringIntFrom reads csv "<str>,<int>" from r
+func ReadCsvStringIntFrom(r io.Reader) (map[string]int, error) {
+	recs := streamCSV(r, 1)
+	defer recs.Close()
+	o := make(map[string]int)
+	for rec := range recs.C {
 		v, err := strconv.Atoi(rec[1])
 		if err != nil {
 			return nil, fmt.Errorf("value parse error: %v", err)
 		}
 		o[rec[0]] = v
 	}
+	if err := recs.Close(); err != nil {
+		return nil, err
+	}
 	return o, nil
 }
 
 // ReadCsvStringFloat reads temporal csv file ith column type "<str>,<float>"
 func ReadCsvStringFloat(csvfp string) (map[string]float64, error) {
-	f, err := os.Open(csvfp)
-	if err != nil {
-		fmt.Printf("ReadCSV failed: %v\n", err)
-		return nil, fmt.Errorf("ReadCSV failed: %v", err)
-	}
-	defer f.Close()
+	return readPath("ReadCsvStringFloat", csvfp, ReadCsvStringFloatFrom)
+}
 
-	recs := LoadCSV(io.Reader(f), 1)
-	o := make(map[string]float64, len(recs)-1)
-	for rec := range recs {
+// ReadCsvStringFloatFrom reads csv "<str>,<float>" from r
+func ReadCsvStringFloatFrom(r io.Reader) (map[string]float64, error) {
+	recs := streamCSV(r, 1)
+	defer recs.Close()
+	o := make(map[string]float64)
+	for rec := range recs.C {
 		v, err := strconv.ParseFloat(rec[1], 64)
 		if err != nil {
 			return nil, fmt.Errorf("value parse error: %v", err)
 		}
 		o[rec[0]] = v
 	}
+	if err := recs.Close(); err != nil {
+		return nil, err
+	}
 	return o, nil
 }
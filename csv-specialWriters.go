@@ -1,10 +1,20 @@
 package mmio
 
-import "time"
+import (
+	"cmp"
+	"io"
+	"sort"
+	"time"
+)
 
+// WriteCsvFloats writes columns d as a csv file with the given header
 func WriteCsvFloats(csvfp, header string, d ...[]float64) error {
-	csv := NewCSVwriter(csvfp)
-	defer csv.Close()
+	return writeOutput(csvfp, false, func(w io.Writer) error { return WriteCsvFloatsTo(w, header, d...) })
+}
+
+// WriteCsvFloatsTo writes the csv to w, see WriteCsvFloats
+func WriteCsvFloatsTo(w io.Writer, header string, d ...[]float64) error {
+	csv := NewCSVwriterTo(w)
 	if err := csv.WriteHead(header); err != nil {
 		return err
 	}
@@ -19,12 +29,17 @@ func WriteCsvFloats(csvfp, header string, d ...[]float64) error {
 			return err
 		}
 	}
-	return nil
+	return csv.CloseE()
 }
 
+// WriteCsvFloats32 writes columns d as a csv file with the given header
 func WriteCsvFloats32(csvfp, header string, d ...[]float32) error {
-	csv := NewCSVwriter(csvfp)
-	defer csv.Close()
+	return writeOutput(csvfp, false, func(w io.Writer) error { return WriteCsvFloats32To(w, header, d...) })
+}
+
+// WriteCsvFloats32To writes the csv to w, see WriteCsvFloats32
+func WriteCsvFloats32To(w io.Writer, header string, d ...[]float32) error {
+	csv := NewCSVwriterTo(w)
 	if err := csv.WriteHead(header); err != nil {
 		return err
 	}
@@ -39,12 +54,17 @@ func WriteCsvFloats32(csvfp, header string, d ...[]float32) error {
 			return err
 		}
 	}
-	return nil
+	return csv.CloseE()
 }
 
+// WriteCsvDateFloats writes columns d as a csv file with a leading date column
 func WriteCsvDateFloats(csvfp, header string, t []time.Time, d ...[]float64) error {
-	csv := NewCSVwriter(csvfp)
-	defer csv.Close()
+	return writeOutput(csvfp, false, func(w io.Writer) error { return WriteCsvDateFloatsTo(w, header, t, d...) })
+}
+
+// WriteCsvDateFloatsTo writes the csv to w, see WriteCsvDateFloats
+func WriteCsvDateFloatsTo(w io.Writer, header string, t []time.Time, d ...[]float64) error {
+	csv := NewCSVwriterTo(w)
 	if err := csv.WriteHead("date," + header); err != nil {
 		return err
 	}
@@ -52,7 +72,7 @@ func WriteCsvDateFloats(csvfp, header string, t []time.Time, d ...[]float64) err
 	nr := len(d[0])
 	for i := 0; i < nr; i++ {
 		iv := make([]interface{}, nc+1)
-		iv[0] = t[i].Format("2006-01-02 15:04:05")
+		iv[0] = t[i].In(dateLocation()).Format("2006-01-02 15:04:05")
 		for j := 0; j < nc; j++ {
 			iv[j+1] = d[j][i]
 		}
@@ -60,22 +80,103 @@ func WriteCsvDateFloats(csvfp, header string, t []time.Time, d ...[]float64) err
 			return err
 		}
 	}
-	return nil
+	return csv.CloseE()
 }
 
+// WriteCsvIntInts writes map[int]int as "key,value" rows sorted by key
 func WriteCsvIntInts(csvfp, header string, ii map[int]int) error {
-	csv := NewCSVwriter(csvfp)
-	defer csv.Close()
+	return WriteCsvMap(csvfp, header, ii)
+}
+
+// WriteCsvIntIntsTo writes the csv to w, see WriteCsvIntInts
+func WriteCsvIntIntsTo(w io.Writer, header string, ii map[int]int) error {
+	return WriteCsvMapTo(w, header, ii)
+}
+
+// WriteCsvMap writes a map as "key,value(s)" rows sorted by key; slice values are expanded into columns
+func WriteCsvMap[K cmp.Ordered, V any](csvfp, header string, m map[K]V) error {
+	return WriteCsvMapFunc(csvfp, header, m, func(a, b K) bool { return a < b })
+}
+
+// WriteCsvMapTo writes the csv to w, see WriteCsvMap
+func WriteCsvMapTo[K cmp.Ordered, V any](w io.Writer, header string, m map[K]V) error {
+	return WriteCsvMapFuncTo(w, header, m, func(a, b K) bool { return a < b })
+}
+
+// WriteCsvMapFunc writes a map as "key,value(s)" rows ordered by less
+func WriteCsvMapFunc[K comparable, V any](csvfp, header string, m map[K]V, less func(a, b K) bool) error {
+	return writeOutput(csvfp, false, func(w io.Writer) error { return WriteCsvMapFuncTo(w, header, m, less) })
+}
+
+// WriteCsvMapFuncTo writes the csv to w, see WriteCsvMapFunc
+func WriteCsvMapFuncTo[K comparable, V any](w io.Writer, header string, m map[K]V, less func(a, b K) bool) error {
+	csv := NewCSVwriterTo(w)
 	if err := csv.WriteHead(header); err != nil {
 		return err
 	}
-	for k, v := range ii {
-		iv := make([]interface{}, 2)
-		iv[0] = k
-		iv[1] = v
-		if err := csv.WriteLine(iv...); err != nil {
+	csv.SetTimeLayout("2006-01-02 15:04:05")
+	csv.SetTimeLocation(dateLocation()) // written without an offset, in the location they are read back in
+	ks := make([]K, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.SliceStable(ks, func(i, j int) bool { return less(ks[i], ks[j]) })
+	for _, k := range ks {
+		if err := csv.WriteLine(k, m[k]); err != nil {
+			return err
+		}
+	}
+	return csv.CloseE()
+}
+
+// WriteCsvDateMap writes a temporal map (e.g., from ReadCsvDateFloats) as "date,value(s)" rows sorted by date
+func WriteCsvDateMap[V any](csvfp, header string, m map[time.Time]V) error {
+	return WriteCsvMapFunc(csvfp, "date,"+header, m, func(a, b time.Time) bool { return a.Before(b) })
+}
+
+// WriteCsvDateMapTo writes the csv to w, see WriteCsvDateMap
+func WriteCsvDateMapTo[V any](w io.Writer, header string, m map[time.Time]V) error {
+	return WriteCsvMapFuncTo(w, "date,"+header, m, func(a, b time.Time) bool { return a.Before(b) })
+}
+
+// WriteCsvDateFloat writes a map of unix time to value (from ReadCsvDateFloat) as "date,value" rows sorted by date
+func WriteCsvDateFloat(csvfp, header string, m map[int64]float64) error {
+	tm := make(map[time.Time]float64, len(m))
+	for u, v := range m {
+		tm[time.Unix(u, 0).UTC()] = v
+	}
+	return WriteCsvDateMap(csvfp, header, tm)
+}
+
+// WriteCsvDateFloatTo writes the csv to w, see WriteCsvDateFloat
+func WriteCsvDateFloatTo(w io.Writer, header string, m map[int64]float64) error {
+	tm := make(map[time.Time]float64, len(m))
+	for u, v := range m {
+		tm[time.Unix(u, 0).UTC()] = v
+	}
+	return WriteCsvDateMapTo(w, header, tm)
+}
+
+// WriteCsvDateFloatFlag writes a flagged timeseries as "date,value,flag", sorted by date
+func WriteCsvDateFloatFlag(csvfp string, fs FlaggedSeries) error {
+	return writeOutput(csvfp, false, func(w io.Writer) error { return WriteCsvDateFloatFlagTo(w, fs) })
+}
+
+// WriteCsvDateFloatFlagTo writes the csv to w, see WriteCsvDateFloatFlag
+func WriteCsvDateFloatFlagTo(w io.Writer, fs FlaggedSeries) error {
+	csv := NewCSVwriterTo(w)
+	if err := csv.WriteHead("date,value,flag"); err != nil {
+		return err
+	}
+	ts := make([]time.Time, 0, len(fs))
+	for t := range fs {
+		ts = append(ts, t)
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Before(ts[j]) })
+	for _, t := range ts {
+		if err := csv.WriteLine(t.In(dateLocation()).Format("2006-01-02 15:04:05"), fs[t].V, fs[t].Flag); err != nil {
 			return err
 		}
 	}
-	return nil
+	return csv.CloseE()
 }
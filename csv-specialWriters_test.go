@@ -0,0 +1,105 @@
+package mmio
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteCsvMap(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		write func(w io.Writer) error
+		want  string
+	}{
+		{"int keys", func(w io.Writer) error {
+			return WriteCsvMapTo(w, "k,v", map[int]float64{10: 1.5, -2: 3, 3: 0})
+		}, "k,v\n-2,3\n3,0\n10,1.5\n"},
+		{"string keys, slice values", func(w io.Writer) error {
+			return WriteCsvMapTo(w, "k,a,b", map[string][]int{"b": {3, 4}, "a": {1, 2}})
+		}, "k,a,b\na,1,2\nb,3,4\n"},
+		{"int ints", func(w io.Writer) error {
+			return WriteCsvIntIntsTo(w, "k,v", map[int]int{2: 20, 1: 10})
+		}, "k,v\n1,10\n2,20\n"},
+		{"custom order", func(w io.Writer) error {
+			return WriteCsvMapFuncTo(w, "k,v", map[int]string{1: "a", 2: "b", 3: "c"}, func(a, b int) bool { return a > b })
+		}, "k,v\n3,c\n2,b\n1,a\n"},
+		{"dates", func(w io.Writer) error {
+			return WriteCsvDateMapTo(w, "a,b", map[time.Time][]float64{t0.Add(time.Hour): {3, 4}, t0: {1, 2}})
+		}, "date,a,b\n2020-01-01 00:00:00,1,2\n2020-01-01 01:00:00,3,4\n"},
+		{"unix dates", func(w io.Writer) error {
+			return WriteCsvDateFloatTo(w, "v", map[int64]float64{t0.Unix() + 60: 2, t0.Unix(): 1})
+		}, "date,v\n2020-01-01 00:00:00,1\n2020-01-01 00:01:00,2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("wrote %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCsvDateMapRoundTrip(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := map[time.Time][]float64{t0: {1, 2.5}, t0.AddDate(0, 1, 0): {-3, 1e-3}}
+	var buf bytes.Buffer
+	if err := WriteCsvDateMapTo(&buf, "a,b", m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadCsvDateFloatsFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("read back %v, want %v", got, m)
+	}
+}
+
+func TestWriteCsvDateMapZones(t *testing.T) {
+	est, cet := time.FixedZone("EST", -5*3600), time.FixedZone("CET", 3600)
+	tests := []struct {
+		name    string
+		readLoc *time.Location // DefaultDateParser.Location
+		keyLoc  *time.Location
+		want    string // first date written
+	}{
+		{"utc keys", time.UTC, time.UTC, "2020-01-01 12:00:00"},
+		{"non-utc keys", time.UTC, est, "2020-01-01 12:00:00"},
+		{"non-utc parser", cet, est, "2020-01-01 13:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(loc *time.Location) { DefaultDateParser.Location = loc }(DefaultDateParser.Location)
+			DefaultDateParser.Location = tt.readLoc
+			t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC).In(tt.keyLoc)
+			m := map[time.Time][]float64{t0: {1}, t0.Add(time.Hour): {2}}
+			var buf bytes.Buffer
+			if err := WriteCsvDateMapTo(&buf, "a", m); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(buf.Bytes(), []byte("\n"+tt.want+",1\n")) {
+				t.Errorf("wrote\n%s\nwant the first date as %s", buf.String(), tt.want)
+			}
+			got, err := ReadCsvDateFloatsFrom(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(m) {
+				t.Fatalf("read back %v, want %v", got, m)
+			}
+			for k, v := range got {
+				if w, ok := m[k.In(tt.keyLoc)]; !ok || !reflect.DeepEqual(v, w) {
+					t.Errorf("read back %v: %v, not an instant written", k, v)
+				}
+			}
+		})
+	}
+}
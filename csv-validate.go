@@ -0,0 +1,316 @@
+package mmio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CsvType is the expected type of a csv column
+type CsvType int
+
+const (
+	CsvAny CsvType = iota
+	CsvFloat
+	CsvInt
+	CsvDate
+)
+
+// CsvColumnRule describes the expected content of a named csv column
+type CsvColumnRule struct {
+	Name       string
+	Type       CsvType
+	Required   bool     // the column must be present in the header
+	NotEmpty   bool     // cells may not be blank ("" or "NA")
+	CheckRange bool     // numeric cells must lie within [Min, Max]
+	Min, Max   float64  // bounds used by CheckRange
+	Allowed    []string // if set, the only values permitted
+	Monotonic  bool     // numeric or date cells must be strictly increasing
+}
+
+// CsvSchema describes the expected layout of a csv file with a single header line
+type CsvSchema struct {
+	Columns    []CsvColumnRule
+	Key        []string // columns whose combined values must be unique
+	AllowExtra bool     // permit columns not listed in Columns
+	MaxErrors  int      // stop collecting after this many violations (0: no limit)
+}
+
+// CsvViolation is a single schema violation; Line and Col (field number, or character
+// position for csv syntax errors) are 1-based, 0 when not applicable
+type CsvViolation struct {
+	Line   int
+	Col    int
+	Column string
+	Value  string
+	Msg    string
+}
+
+func (v CsvViolation) String() string {
+	s := fmt.Sprintf("line %d", v.Line)
+	if v.Col > 0 {
+		s += fmt.Sprintf(", column %d", v.Col)
+	}
+	if len(v.Column) > 0 {
+		s += fmt.Sprintf(" (%s)", v.Column)
+	}
+	if len(v.Value) > 0 {
+		s += fmt.Sprintf(" %q", v.Value)
+	}
+	return s + ": " + v.Msg
+}
+
+// CsvReport collects the violations found by ValidateCSV
+type CsvReport struct {
+	File       string
+	Rows       int
+	Violations []CsvViolation
+	Truncated  bool // MaxErrors was reached
+}
+
+// OK returns true if no violations were found
+func (r *CsvReport) OK() bool { return len(r.Violations) == 0 }
+
+// Err returns nil if the file is valid, otherwise an error summarizing the violations
+func (r *CsvReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return errors.New(r.String())
+}
+
+func (r *CsvReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d rows, %d violations", r.File, r.Rows, len(r.Violations))
+	if r.Truncated {
+		b.WriteString(" (truncated)")
+	}
+	for _, v := range r.Violations {
+		b.WriteString("\n  " + v.String())
+	}
+	return b.String()
+}
+
+var errCsvMaxErrors = errors.New("maximum number of violations reached")
+
+func (r *CsvReport) add(max int, v CsvViolation) error {
+	r.Violations = append(r.Violations, v)
+	if max > 0 && len(r.Violations) >= max {
+		r.Truncated = true
+		return errCsvMaxErrors
+	}
+	return nil
+}
+
+// ValidateCSV checks a csv file against a schema and reports every violation with its line and column.
+// The returned error is for failures to read the file; data problems are reported in CsvReport.
+func ValidateCSV(fp string, schema CsvSchema) (*CsvReport, error) {
+	f, err := openRead(fp)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateCSV: %v", err)
+	}
+	defer f.Close()
+	return ValidateCSVFrom(f, fp, schema)
+}
+
+// ValidateCSVFrom checks the csv read from r against schema; name is reported as CsvReport.File
+func ValidateCSVFrom(r io.Reader, name string, schema CsvSchema) (*CsvReport, error) {
+	rpt := &CsvReport{File: name}
+	if err := validateCSV(r, schema, rpt); err != nil && err != errCsvMaxErrors {
+		return nil, fmt.Errorf("ValidateCSV %s: %v", name, err)
+	}
+	return rpt, nil
+}
+
+func validateCSV(rd io.Reader, schema CsvSchema, rpt *CsvReport) error {
+	r := csv.NewReader(rd)
+	r.FieldsPerRecord = -1
+	head, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("cannot read header: %v", err)
+	}
+	ix := make(map[string]int, len(head))
+	for j, h := range head {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF"))
+		if _, ok := ix[h]; ok {
+			if err := rpt.add(schema.MaxErrors, CsvViolation{Line: 1, Col: j + 1, Column: h, Msg: "duplicate column name"}); err != nil {
+				return err
+			}
+		}
+		ix[h] = j
+	}
+
+	type rule struct {
+		CsvColumnRule
+		j       int
+		allowed map[string]bool
+		lastF   float64
+		lastT   time.Time
+		started bool
+	}
+	var rules []*rule
+	known := make(map[string]bool, len(schema.Columns))
+	for _, c := range schema.Columns {
+		known[c.Name] = true
+		j, ok := ix[c.Name]
+		if !ok {
+			if c.Required {
+				if err := rpt.add(schema.MaxErrors, CsvViolation{Line: 1, Column: c.Name, Msg: "required column missing"}); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		rl := &rule{CsvColumnRule: c, j: j}
+		if len(c.Allowed) > 0 {
+			rl.allowed = make(map[string]bool, len(c.Allowed))
+			for _, a := range c.Allowed {
+				rl.allowed[a] = true
+			}
+		}
+		rules = append(rules, rl)
+	}
+	if !schema.AllowExtra {
+		for j, h := range head {
+			if h = strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF")); !known[h] {
+				if err := rpt.add(schema.MaxErrors, CsvViolation{Line: 1, Col: j + 1, Column: h, Msg: "unexpected column"}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	var kj []int
+	keyOK := true
+	for _, k := range schema.Key {
+		j, ok := ix[k]
+		if !ok {
+			if err := rpt.add(schema.MaxErrors, CsvViolation{Line: 1, Column: k, Msg: "key column missing"}); err != nil {
+				return err
+			}
+			keyOK = false
+			continue
+		}
+		kj = append(kj, j)
+	}
+	if !keyOK {
+		kj = nil // uniqueness cannot be checked, the other rules still are
+	}
+
+	keys, dp := make(map[string]int), DefaultDateParser.Clone()
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				if err := rpt.add(schema.MaxErrors, CsvViolation{Line: pe.Line, Col: pe.Column, Msg: pe.Err.Error()}); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		rpt.Rows++
+		line, _ := r.FieldPos(0)
+		if len(rec) != len(head) {
+			if err := rpt.add(schema.MaxErrors, CsvViolation{Line: line, Msg: fmt.Sprintf("%d fields, expected %d", len(rec), len(head))}); err != nil {
+				return err
+			}
+		}
+		for _, rl := range rules {
+			if rl.j >= len(rec) {
+				continue
+			}
+			s := strings.TrimSpace(rec[rl.j])
+			ln, _ := r.FieldPos(rl.j)
+			viol := func(msg string) error {
+				return rpt.add(schema.MaxErrors, CsvViolation{Line: ln, Col: rl.j + 1, Column: rl.Name, Value: rec[rl.j], Msg: msg})
+			}
+			if len(s) == 0 || s == "NA" {
+				if rl.NotEmpty {
+					if err := viol("empty value"); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if rl.allowed != nil && !rl.allowed[s] {
+				if err := viol("value not allowed"); err != nil {
+					return err
+				}
+			}
+			switch rl.Type {
+			case CsvFloat, CsvInt:
+				var v float64
+				if rl.Type == CsvInt {
+					i, err := strconv.Atoi(s)
+					if err != nil {
+						if err := viol("not an integer"); err != nil {
+							return err
+						}
+						continue
+					}
+					v = float64(i)
+				} else if v, err = strconv.ParseFloat(s, 64); err != nil || math.IsNaN(v) {
+					if err := viol("not a number"); err != nil {
+						return err
+					}
+					continue
+				}
+				if rl.CheckRange && (v < rl.Min || v > rl.Max) {
+					if err := viol(fmt.Sprintf("out of range [%v, %v]", rl.Min, rl.Max)); err != nil {
+						return err
+					}
+				}
+				if rl.Monotonic {
+					if rl.started && v <= rl.lastF {
+						if err := viol("not increasing"); err != nil {
+							return err
+						}
+					}
+					rl.lastF, rl.started = v, true
+				}
+			case CsvDate:
+				t, err := dp.Parse(s)
+				if err != nil {
+					if err := viol("not a date"); err != nil {
+						return err
+					}
+					continue
+				}
+				if rl.Monotonic {
+					if rl.started && !t.After(rl.lastT) {
+						if err := viol("date not increasing"); err != nil {
+							return err
+						}
+					}
+					rl.lastT, rl.started = t, true
+				}
+			}
+		}
+		if len(kj) > 0 {
+			k := make([]string, len(kj))
+			for n, j := range kj {
+				if j < len(rec) {
+					k[n] = strings.TrimSpace(rec[j])
+				}
+			}
+			ks := strings.Join(k, "\x00")
+			if l0, ok := keys[ks]; ok {
+				if err := rpt.add(schema.MaxErrors, CsvViolation{Line: line, Column: strings.Join(schema.Key, ","), Value: strings.Join(k, ","), Msg: fmt.Sprintf("duplicate key (first on line %d)", l0)}); err != nil {
+					return err
+				}
+			} else {
+				keys[ks] = line
+			}
+		}
+	}
+	return nil
+}
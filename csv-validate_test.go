@@ -0,0 +1,77 @@
+package mmio
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateCSV(t *testing.T) {
+	obs := CsvSchema{Columns: []CsvColumnRule{
+		{Name: "date", Type: CsvDate, Required: true, NotEmpty: true, Monotonic: true},
+		{Name: "q", Type: CsvFloat, CheckRange: true, Min: 0, Max: 100},
+		{Name: "n", Type: CsvInt},
+		{Name: "flag", Allowed: []string{"E", "B"}},
+	}}
+	tests := []struct {
+		name   string
+		in     string
+		schema CsvSchema
+		want   []string // line:col:msg
+	}{
+		{"valid", "date,q,n,flag\n2020-01-01,1.5,3,E\n2020-01-02,NA,,\n", obs, nil},
+		{"cell violations", "date,q,n,flag\n2020-01-01,-1,3.5,X\n,abc,2,B\n", obs, []string{
+			"2:2:out of range [0, 100]", "2:3:not an integer", "2:4:value not allowed",
+			"3:1:empty value", "3:2:not a number"}},
+		{"dates", "date,q,n,flag\n2020-01-02,1,1,E\n2020-01-01,1,1,E\nlater,1,1,E\n", obs, []string{
+			"3:1:date not increasing", "4:1:not a date"}},
+		{"header", "date,q,q,extra\n2020-01-01,1,1,1\n", CsvSchema{Columns: []CsvColumnRule{
+			{Name: "date"}, {Name: "q"}, {Name: "n", Required: true}}}, []string{
+			"1:3:duplicate column name", "1:0:required column missing", "1:4:unexpected column"}},
+		{"extra allowed", "date,other\n2020-01-01,x\n", CsvSchema{AllowExtra: true, Columns: []CsvColumnRule{{Name: "date", Type: CsvDate}}}, nil},
+		{"field count", "a,b\n1,2\n3\n", CsvSchema{AllowExtra: true}, []string{"3:0:1 fields, expected 2"}},
+		{"duplicate key", "id,t,v\n1,a,1\n2,a,1\n1,a,2\n", CsvSchema{AllowExtra: true, Key: []string{"id", "t"}}, []string{
+			"4:0:duplicate key (first on line 2)"}},
+		{"missing key column", "id,v\n1,x\n1,2\n", CsvSchema{Key: []string{"id", "t"}, Columns: []CsvColumnRule{{Name: "id"}, {Name: "v", Type: CsvFloat}}}, []string{
+			"1:0:key column missing", "2:2:not a number"}},
+		{"monotonic numbers", "t\n1\n2\n2\n", CsvSchema{Columns: []CsvColumnRule{{Name: "t", Type: CsvFloat, Monotonic: true}}}, []string{
+			"4:1:not increasing"}},
+		{"max errors", "q\nx\ny\nz\n", CsvSchema{MaxErrors: 2, Columns: []CsvColumnRule{{Name: "q", Type: CsvFloat}}}, []string{
+			"2:1:not a number", "3:1:not a number"}},
+		{"quoted field on its own line", "a,b\n1,\"x\ny\"\n2,abc\n", CsvSchema{Columns: []CsvColumnRule{{Name: "a", Type: CsvInt}, {Name: "b", Type: CsvInt}}}, []string{
+			"2:2:not an integer", "4:2:not an integer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpt, err := ValidateCSVFrom(strings.NewReader(tt.in), "test.csv", tt.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range rpt.Violations {
+				got = append(got, fmt.Sprintf("%d:%d:%s", v.Line, v.Col, v.Msg))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violations\n%q\nwant\n%q", got, tt.want)
+			}
+			if rpt.OK() != (len(tt.want) == 0) || (rpt.Err() == nil) != rpt.OK() {
+				t.Errorf("OK %v, Err %v", rpt.OK(), rpt.Err())
+			}
+			if tt.schema.MaxErrors > 0 && !rpt.Truncated {
+				t.Error("MaxErrors reached but report not truncated")
+			}
+		})
+	}
+}
+
+func TestValidateCSVMissingKeyScansRows(t *testing.T) {
+	schema := CsvSchema{Key: []string{"id", "t"}, Columns: []CsvColumnRule{{Name: "id"}, {Name: "v", Type: CsvFloat}}}
+	rpt, err := ValidateCSVFrom(strings.NewReader("id,v\n1,x\n1,2\n3,4\n"), "test.csv", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rpt.Rows != 3 || len(rpt.Violations) != 2 {
+		t.Errorf("%d rows, violations %v; want 3 rows, the missing key and the bad value", rpt.Rows, rpt.Violations)
+	}
+}
@@ -1,6 +1,7 @@
 package mmio
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -8,20 +9,27 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // ReadCSV general CSV reader (must be completely numeric)
 func ReadCSV(filepath string, nHeaderLines int) ([][]float64, error) {
-	f, err := os.Open(filepath)
+	f, err := openRead(filepath)
 	if err != nil {
 		fmt.Printf("ReadCSV failed: %v\n", err)
 		return nil, fmt.Errorf("ReadCSV failed: %v", err)
 	}
 	defer f.Close()
+	return ReadCSVFrom(f, nHeaderLines)
+}
+
+// ReadCSVFrom reads a completely numeric csv from r, see ReadCSV
+func ReadCSVFrom(r io.Reader, nHeaderLines int) ([][]float64, error) {
 	var fout [][]float64
-	recs := LoadCSV(io.Reader(f), nHeaderLines)
-	// for rec := range LoadCSV(io.Reader(f), nHeaderLines) {
-	for rec := range recs {
+	recs := streamCSV(r, nHeaderLines)
+	defer recs.Close()
+	for rec := range recs.C {
 		f1 := make([]float64, 0, len(rec))
 		for i, c := range rec {
 			f2, err := strconv.ParseFloat(c, 64)
@@ -33,7 +41,58 @@ func ReadCSV(filepath string, nHeaderLines int) ([][]float64, error) {
 		}
 		fout = append(fout, f1)
 	}
-	return fout, err
+	if err := recs.Close(); err != nil {
+		return nil, fmt.Errorf("ReadCSV failed: %v", err)
+	}
+	return fout, nil
+}
+
+// csvStream reads the records of a csv in a goroutine, as LoadCSV, but can be stopped early
+// and reports read errors instead of exiting. Close stops the reader and waits for it, so the
+// underlying file can be closed safely afterwards.
+type csvStream struct {
+	C    chan []string
+	done chan struct{}
+	fin  chan struct{}
+	once sync.Once
+	err  error
+}
+
+func streamCSV(rc io.Reader, nHeaderLines int) *csvStream {
+	s := &csvStream{C: make(chan []string), done: make(chan struct{}), fin: make(chan struct{})}
+	go func() {
+		defer close(s.fin)
+		defer close(s.C)
+		r := csv.NewReader(rc)
+		for l := 0; l < nHeaderLines; l++ {
+			if _, err := r.Read(); err != nil { //read header(s)
+				s.err = err
+				return
+			}
+		}
+		for {
+			rec, err := r.Read()
+			if err != nil {
+				if err != io.EOF {
+					s.err = err
+				}
+				return
+			}
+			select {
+			case s.C <- rec:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Close stops the reader, waits for it to return and returns its read error, if any
+func (s *csvStream) Close() error {
+	s.once.Do(func() { close(s.done) })
+	<-s.fin
+	return s.err
 }
 
 func ncolsCSV(rc io.Reader) int {
@@ -72,18 +131,26 @@ func LoadCSV(rc io.Reader, nHeaderLines int) (ch chan []string) {
 }
 
 func LoadCsvArray(fp string, nHeaderLines int) [][]string {
-	a := make([][]string, 0)
-
-	f, err := os.Open(fp)
+	f, err := openRead(fp)
 	if err != nil {
 		panic(err)
 	}
 	defer f.Close()
-	r := csv.NewReader(io.Reader(f))
+	a, err := LoadCsvArrayFrom(f, nHeaderLines)
+	if err != nil {
+		log.Fatalf("LoadCSV error: %v", err)
+	}
+	return a
+}
+
+// LoadCsvArrayFrom reads all records of r following nHeaderLines header lines
+func LoadCsvArrayFrom(rc io.Reader, nHeaderLines int) ([][]string, error) {
+	a := make([][]string, 0)
+	r := csv.NewReader(rc)
 
 	for l := 0; l < nHeaderLines; l++ {
 		if _, err := r.Read(); err != nil { //read header(s)
-			log.Fatalf("LoadCSV error: %v", err)
+			return nil, err
 		}
 		// if rec, err := r.Read(); err != nil { //read header(s)
 		// 	log.Fatalf("LoadCSV error: %v", err)
@@ -98,22 +165,33 @@ func LoadCsvArray(fp string, nHeaderLines int) [][]string {
 			if err == io.EOF {
 				break
 			}
-			log.Fatalf("LoadCSV error: %v", err)
+			return nil, err
 		}
 		a = append(a, rec)
 	}
-	return a
+	return a, nil
 }
 
 // CSVwriter general CSV writer
 type CSVwriter struct {
-	file   *os.File
+	file   outputFile
 	writer *csv.Writer
+
+	floatFmt            FloatFormat
+	colFmt              map[int]FloatFormat
+	timeLayout          string
+	timeLoc             *time.Location
+	nan, posInf, negInf *string
+
+	flushEvery int           // flush after this many lines (0: only on Close)
+	flushIntvl time.Duration // flush when this much time has passed since the last flush
+	nlines     int
+	lastFlush  time.Time
 }
 
 // NewCSVwriter CSVwriter constructor
 func NewCSVwriter(fp string) *CSVwriter {
-	file, err := os.Create(fp)
+	file, err := createOutput(fp, false)
 	if err != nil {
 		log.Fatal("Cannot create file", err)
 	}
@@ -124,58 +202,253 @@ func NewCSVwriter(fp string) *CSVwriter {
 	return nc
 }
 
-// Close closes CSVwriter
+// NewCSVwriterTo constructs a CSVwriter over w; Close flushes but does not close w
+func NewCSVwriterTo(w io.Writer) *CSVwriter {
+	return &CSVwriter{
+		file:   nopOutput{w},
+		writer: csv.NewWriter(w),
+	}
+}
+
+// NewCSVwriterAtomic constructs a CSVwriter that only replaces fp once Close succeeds (see AtomicWrites)
+func NewCSVwriterAtomic(fp string) (*CSVwriter, error) {
+	file, err := createOutput(fp, true)
+	if err != nil {
+		return nil, fmt.Errorf("NewCSVwriterAtomic: %v", err)
+	}
+	nc := &CSVwriter{
+		file:   file,
+		writer: csv.NewWriter(file),
+	}
+	return nc, nil
+}
+
+// NewCSVwriterAppend opens a CSVwriter that appends to fp. If fp exists and is not empty, its header
+// must match h (comma-delimited) otherwise an error is returned; a new or empty file gets h written as
+// its header. By default, the writer flushes after every line so that partial output survives a crash;
+// a last record cut short by such a crash is removed on the next append, and a missing final newline is
+// added (see repairTail), both reported with log.Printf. With LockAppends set, the file stays exclusively locked until Close.
+func NewCSVwriterAppend(fp, h string) (*CSVwriter, error) {
+	file, err := openAppend(fp, os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		return nil, fmt.Errorf("NewCSVwriterAppend: %v", err)
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("NewCSVwriterAppend: %v", err)
+	}
+	hwant := strings.Split(h, ",")
+	if fi.Size() > 0 {
+		if err := checkCsvHeader(file, hwant); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("NewCSVwriterAppend %s: %v", fp, err)
+		}
+		msg, err := repairTail(file, fi.Size(), len(hwant))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("NewCSVwriterAppend %s: %v", fp, err)
+		}
+		if len(msg) > 0 {
+			log.Printf("NewCSVwriterAppend %s: %s", fp, msg)
+		}
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("NewCSVwriterAppend: %v", err)
+	}
+	nc := &CSVwriter{
+		file:       plainFile{File: file},
+		writer:     csv.NewWriter(file),
+		flushEvery: 1,
+		lastFlush:  time.Now(),
+	}
+	if fi.Size() == 0 {
+		if err := nc.WriteHead(h); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return nc, nil
+}
+
+// checkCsvHeader compares the first record of f with the expected header
+func checkCsvHeader(f *os.File, hwant []string) error {
+	br := bufio.NewReader(f)
+	if err := RemoveBOM(br); err != nil {
+		return fmt.Errorf("cannot read header: %v", err)
+	}
+	r := csv.NewReader(br)
+	r.FieldsPerRecord = -1
+	hgot, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("cannot read header: %v", err)
+	}
+	if len(hgot) != len(hwant) {
+		return fmt.Errorf("header mismatch: existing %v, requested %v", hgot, hwant)
+	}
+	for i := range hgot {
+		if strings.TrimSpace(hgot[i]) != strings.TrimSpace(hwant[i]) {
+			return fmt.Errorf("header mismatch: existing %v, requested %v", hgot, hwant)
+		}
+	}
+	return nil
+}
+
+// repairTail makes an appended file end on a record boundary. A file that does not end with a
+// newline either lost the end of its last record to an interrupted write, which is then removed,
+// or comes from a tool that omits the final newline, which is then added. The last record is
+// complete when it parses with at least ncol fields and does not end with a comma (a write cut
+// off after a delimiter would otherwise leave a bogus empty last field); quoted newlines are
+// taken into account.
+// The repair made, if any, is described by the returned string.
+func repairTail(f *os.File, size int64, ncol int) (string, error) {
+	b := make([]byte, 1)
+	if _, err := f.ReadAt(b, size-1); err != nil {
+		return "", err
+	}
+	if b[0] == '\n' {
+		return "", nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	r := csv.NewReader(bufio.NewReader(f))
+	r.FieldsPerRecord, r.ReuseRecord = -1, true
+	var start int64
+	var nf int
+	var perr error
+	for {
+		off := r.InputOffset()
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		start, nf, perr = off, len(rec), err
+	}
+	if perr == nil && nf >= ncol && b[0] != ',' {
+		if _, err := f.WriteAt([]byte("\n"), size); err != nil {
+			return "", err
+		}
+		return "added the missing newline after the last record", nil
+	}
+	if err := f.Truncate(start); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("removed an incomplete last record (%d bytes)", size-start), nil
+}
+
+// SetFlush sets how often buffered lines are flushed to file: every n lines and/or
+// once interval has passed since the last flush. Zero values disable the respective trigger.
+func (w *CSVwriter) SetFlush(n int, interval time.Duration) {
+	w.flushEvery, w.flushIntvl = n, interval
+	w.lastFlush = time.Now()
+}
+
+// Flush writes any buffered lines to file
+func (w *CSVwriter) Flush() error {
+	w.writer.Flush()
+	w.nlines, w.lastFlush = 0, time.Now()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("CSVwriter.Flush error: %v", err)
+	}
+	return nil
+}
+
+func (w *CSVwriter) autoFlush() error {
+	w.nlines++
+	if (w.flushEvery > 0 && w.nlines >= w.flushEvery) || (w.flushIntvl > 0 && time.Since(w.lastFlush) >= w.flushIntvl) {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Close closes CSVwriter; errors are logged, use CloseE to handle them
 func (w *CSVwriter) Close() {
+	if err := w.CloseE(); err != nil {
+		log.Println(err)
+	}
+}
+
+// CloseE flushes and closes CSVwriter, returning any write or close error
+// (in atomic mode, the target file is only replaced when CloseE returns nil)
+func (w *CSVwriter) CloseE() error {
 	w.writer.Flush()
-	w.file.Close()
+	if err := w.writer.Error(); err != nil {
+		w.file.Abort()
+		return fmt.Errorf("CSVwriter.Close error: %v", err)
+	}
+	return w.file.Close()
 }
 
-// WriteLine general CSV line writer method for CSVwriter
-func (w *CSVwriter) WriteLine(data ...interface{}) error {
+// Abort closes CSVwriter discarding its output (in atomic mode, the target file is left untouched)
+func (w *CSVwriter) Abort() error {
+	return w.file.Abort()
+}
 
-	n := len(data) // get line length
-	for _, v := range data {
-		switch v.(type) {
-		case []float64:
-			for range v.([]float64) {
-				n++
-			}
-			n--
-		case []string, []int, []float32:
-			return fmt.Errorf("CSVwriter.WriteLine TODO: lists of type %T", v)
-		default:
-		}
+// finish is deferred by writer functions: it aborts if *err is set, otherwise closes and reports the close error
+func (w *CSVwriter) finish(err *error) {
+	if *err != nil {
+		w.Abort()
+	} else {
+		*err = w.CloseE()
 	}
+}
 
-	a, ii := make([]string, n), 0
+// WriteLine general CSV line writer method for CSVwriter.
+// Slices are expanded into consecutive columns.
+func (w *CSVwriter) WriteLine(data ...interface{}) error {
+	a := make([]string, 0, len(data))
 	for _, v := range data {
-		switch v.(type) {
+		switch vv := v.(type) {
 		case []float64:
-			for _, vv := range v.([]float64) {
-				a[ii] = fmt.Sprint(vv)
-				ii++
+			for _, f := range vv {
+				a = append(a, w.formatFloat(len(a), f, 64))
+			}
+		case []float32:
+			for _, f := range vv {
+				a = append(a, w.formatFloat(len(a), float64(f), 32))
+			}
+		case []int:
+			for _, i := range vv {
+				a = append(a, strconv.Itoa(i))
+			}
+		case []int32:
+			for _, i := range vv {
+				a = append(a, strconv.FormatInt(int64(i), 10))
+			}
+		case []int64:
+			for _, i := range vv {
+				a = append(a, strconv.FormatInt(i, 10))
+			}
+		case []string:
+			a = append(a, vv...)
+		case []time.Time:
+			for _, t := range vv {
+				a = append(a, w.formatTime(t))
+			}
+		case []interface{}:
+			for _, iv := range vv {
+				a = append(a, w.formatValue(len(a), iv))
 			}
 		default:
-			a[ii] = fmt.Sprint(v)
-			ii++
+			a = append(a, w.formatValue(len(a), v))
 		}
 	}
 
 	if err := w.writer.Write(a); err != nil {
 		return fmt.Errorf("CSVwriter.WriteLine error: %v", err)
 	}
-	return nil
+	return w.autoFlush()
 }
 
 // WriteHead add header row to CSVwriter
 func (w *CSVwriter) WriteHead(h string) error {
-	var err error
 	a := strings.Split(h, ",")
-	err = w.writer.Write(a)
-	if err != nil {
-		log.Fatal("Cannot write to file", err)
+	if err := w.writer.Write(a); err != nil {
+		return fmt.Errorf("CSVwriter.WriteHead cannot write to file: %v", err)
 	}
-	return nil
+	return w.autoFlush()
 }
 
 // WriteCSV2d writes csv from a complete dataset dat[row][col]
@@ -0,0 +1,53 @@
+package mmio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVwriterAppendRepairsTail(t *testing.T) {
+	tests := []struct {
+		name, existing, want string
+	}{
+		{"complete", "h1,h2\n1,2\n", "h1,h2\n1,2\n3,4\n"},
+		{"missing final newline", "h1,h2\n1,2", "h1,h2\n1,2\n3,4\n"},
+		{"header only without newline", "h1,h2", "h1,h2\n3,4\n"},
+		{"interrupted record after a delimiter", "h1,h2\n1,2\n5,", "h1,h2\n1,2\n3,4\n"},
+		{"interrupted record, extra fields", "h1,h2\n1,2\n5,6,", "h1,h2\n1,2\n3,4\n"},
+		{"quoted empty last field", "h1,h2\n1,\"\"", "h1,h2\n1,\"\"\n3,4\n"},
+		{"interrupted record, too few fields", "h1,h2\n1,2\n5", "h1,h2\n1,2\n3,4\n"},
+		{"interrupted quoted field", "h1,h2\n1,2\n\"a\nb", "h1,h2\n1,2\n3,4\n"},
+		{"quoted newline in last record", "h1,h2\n1,\"a\nb\"", "h1,h2\n1,\"a\nb\"\n3,4\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := filepath.Join(t.TempDir(), "a.csv")
+			if err := os.WriteFile(fp, []byte(tt.existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+			w, err := NewCSVwriterAppend(fp, "h1,h2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteLine(3, 4); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.CloseE(); err != nil {
+				t.Fatal(err)
+			}
+			b, _ := os.ReadFile(fp)
+			if string(b) != tt.want {
+				t.Errorf("got %q, want %q", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVwriterAppendHeaderMismatch(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "a.csv")
+	os.WriteFile(fp, []byte("x,y\n1,2\n"), 0644)
+	if _, err := NewCSVwriterAppend(fp, "h1,h2"); err == nil {
+		t.Fatal("expected a header mismatch")
+	}
+}
@@ -1,30 +1,226 @@
 package mmio
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func dateParse(s string) (time.Time, error) {
-	t, err := time.Parse("2006-01-02", s)
-	if err != nil {
-		t, err = time.Parse("2006-01-02 15:04:05", s)
+// DateParser parses date/time strings by trying a list of layouts (see time.Parse). The layout
+// that last succeeded is tried first, so that the remaining rows of a file parse quickly.
+// A DateParser is not safe for concurrent use; use Clone to give each goroutine its own.
+type DateParser struct {
+	Location    *time.Location // location given to timestamps without an offset (default UTC)
+	DayFirst    bool           // prefer dd/mm over mm/dd when a date could be read either way
+	ExcelSerial bool           // accept Excel serial dates (e.g., 43831.5) when no layout matches (off by default)
+	Strict      bool           // return an error on day/month orderings that are ambiguous (order not yet established)
+	Ambiguous   int            // number of ambiguous day/month orderings parsed so far (resolved with DayFirst)
+
+	layouts []string
+	last    int
+	dmOrder dayMonthOrder // established by the first date that only parses one way
+}
+
+type dayMonthOrder int
+
+const (
+	dmUnknown dayMonthOrder = iota
+	dmMonthFirst
+	dmDayFirst
+)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05 -0700 MST", // fmt.Sprint(time.Time)
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05Z07:00",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05Z0700",
+	"20060102",
+	"200601021504",
+	"20060102150405",
+}
+
+// day/month ordered layouts, listed mm/dd first; the unpadded ones read "1/5/2020"
+var dateSlashLayouts = [][2]string{
+	{"01/02/2006", "02/01/2006"},
+	{"01/02/2006 15:04", "02/01/2006 15:04"},
+	{"01/02/2006 15:04:05", "02/01/2006 15:04:05"},
+	{"1/2/2006", "2/1/2006"},
+	{"1/2/2006 15:04", "2/1/2006 15:04"},
+	{"1/2/2006 15:04:05", "2/1/2006 15:04:05"},
+}
+
+// DefaultDateParser holds the configuration used by the ReadCsvDate* readers, each of which
+// parses with its own Clone. Set its Location, DayFirst, etc. before reading. Ambiguous
+// dates are read mm/dd by default.
+var DefaultDateParser = NewDateParser(time.UTC, false)
+
+// dateLocation is the location the ReadCsvDate* readers give to dates without an offset;
+// the date writers convert to it so that their output reads back as the same instants
+func dateLocation() *time.Location {
+	if DefaultDateParser.Location == nil {
+		return time.UTC
+	}
+	return DefaultDateParser.Location
+}
+
+// NewDateParser returns a DateParser with the built-in layouts. Timestamps without an offset
+// are read in loc (UTC if nil). Excel serial dates are only accepted once ExcelSerial is set.
+func NewDateParser(loc *time.Location, dayFirst bool) *DateParser {
+	if loc == nil {
+		loc = time.UTC
+	}
+	p := &DateParser{Location: loc, DayFirst: dayFirst}
+	p.layouts = append(p.layouts, dateLayouts...)
+	for _, l := range dateSlashLayouts {
+		p.layouts = append(p.layouts, l[0], l[1])
+	}
+	return p
+}
+
+// Clone returns a copy of the parser configuration with its own state
+func (p *DateParser) Clone() *DateParser {
+	c := *p
+	c.layouts = append([]string(nil), p.layouts...)
+	c.last, c.Ambiguous, c.dmOrder = 0, 0, dmUnknown
+	return &c
+}
+
+// AddLayout registers additional layouts, tried before the built-in ones
+func (p *DateParser) AddLayout(layouts ...string) {
+	p.layouts = append(append([]string(nil), layouts...), p.layouts...)
+	p.last = 0
+}
+
+// Layout returns the layout that last succeeded
+func (p *DateParser) Layout() string {
+	return p.layouts[p.last]
+}
+
+// Parse converts s to a time.Time
+func (p *DateParser) Parse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if t, err := time.ParseInLocation(p.layouts[p.last], s, loc); err == nil {
+		return p.resolveDayMonth(p.layouts[p.last], s, t, loc)
+	}
+	for i, l := range p.layouts {
+		if i == p.last {
+			continue
+		}
+		if t, err := time.ParseInLocation(l, s, loc); err == nil {
+			p.last = i
+			return p.resolveDayMonth(l, s, t, loc)
+		}
+	}
+	if p.ExcelSerial {
+		if v, err := strconv.ParseFloat(s, 64); err == nil && v > 0 && v < 2958466 { // up to year 9999
+			return ExcelSerialToTime(v, loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("DateParser: cannot parse date %q", s)
+}
+
+// resolveDayMonth checks dates that would also parse with day and month swapped. The first
+// date valid in only one order (a day above 12) establishes the order of the following ones;
+// until then, ambiguous dates follow DayFirst. A later date valid only in the other order is an
+// error. Dates with day == month read the same either way.
+func (p *DateParser) resolveDayMonth(layout, s string, t time.Time, loc *time.Location) (time.Time, error) {
+	for _, l := range dateSlashLayouts {
+		var alt string
+		order := dmMonthFirst
+		switch layout {
+		case l[0]:
+			alt = l[1]
+		case l[1]:
+			alt, order = l[0], dmDayFirst
+		default:
+			continue
+		}
+		ta, err := time.ParseInLocation(alt, s, loc)
 		if err != nil {
-			t, err = time.Parse("2006-01-02T15:04:05", s)
-			if err != nil {
-				t, err = time.Parse("2006-01-02 15:04:05 +0000 UTC", s)
-				if err != nil {
-					t, err = time.Parse("2006-01-02 15:04:05+00:00", s)
-					if err != nil {
-						t, err = time.Parse("2006-01-02 15:04", s)
-						if err != nil {
-							return time.Time{}, err
-						}
-					}
-				}
+			if p.dmOrder == dmUnknown {
+				p.dmOrder = order
+			} else if p.dmOrder != order {
+				return time.Time{}, fmt.Errorf("DateParser: %q contradicts the day/month order of earlier dates", s)
+			}
+			return t, nil
+		}
+		if ta.Equal(t) {
+			return t, nil
+		}
+		want := p.dmOrder
+		if want == dmUnknown {
+			p.Ambiguous++
+			if p.Strict {
+				return time.Time{}, fmt.Errorf("DateParser: ambiguous day/month ordering in %q", s)
+			}
+			want = dmMonthFirst
+			if p.DayFirst {
+				want = dmDayFirst
+			}
+		}
+		if want == order {
+			return t, nil
+		}
+		for i, ll := range p.layouts {
+			if ll == alt {
+				p.last = i
+				break
 			}
 		}
+		return ta, nil
 	}
 	return t, nil
 }
 
+// ParseYearDay builds a date from separate year and day-of-year (1-366) columns
+func (p *DateParser) ParseYearDay(year, doy string) (time.Time, error) {
+	y, err := strconv.Atoi(strings.TrimSpace(year))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("DateParser: invalid year %q", year)
+	}
+	d, err := strconv.Atoi(strings.TrimSpace(doy))
+	if err != nil || d < 1 || d > 366 || (d == 366 && !isLeap(y)) {
+		return time.Time{}, fmt.Errorf("DateParser: invalid day of year %q for %d", doy, y)
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(y, time.January, d, 0, 0, 0, 0, loc), nil
+}
+
+// ExcelSerialToTime converts an Excel (1900 date system) serial date to time, rounded to the second
+func ExcelSerialToTime(v float64, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	d := math.Floor(v)
+	s := math.Round((v - d) * 86400.)
+	return time.Date(1899, time.December, 30, 0, 0, int(s), 0, loc).AddDate(0, 0, int(d))
+}
+
+// TimeToExcelSerial converts a time's wall clock to an Excel (1900 date system) serial date
+func TimeToExcelSerial(t time.Time) float64 {
+	y, m, d := t.Date()
+	w := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	e := time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	return float64(w.Unix()-e.Unix())/86400. + float64(w.Nanosecond())/86400e9
+}
+
 // // DayDate returns the input time as a date
 // func DayDate(t time.Time) time.Time {
 // 	year, month, day := t.Date()
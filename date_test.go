@@ -0,0 +1,109 @@
+package mmio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateParserDayMonth(t *testing.T) {
+	d := func(y int, m time.Month, dd int) time.Time { return time.Date(y, m, dd, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		dayFirst bool
+		in       []string
+		want     []time.Time
+	}{
+		{"dd/mm starting day==month", true,
+			[]string{"01/01/2020", "02/01/2020", "03/01/2020", "13/01/2020", "04/02/2020"},
+			[]time.Time{d(2020, 1, 1), d(2020, 1, 2), d(2020, 1, 3), d(2020, 1, 13), d(2020, 2, 4)}},
+		{"mm/dd established despite DayFirst", true,
+			[]string{"01/01/2020", "01/13/2020", "02/01/2020"},
+			[]time.Time{d(2020, 1, 1), d(2020, 1, 13), d(2020, 2, 1)}},
+		{"dd/mm established without DayFirst", false,
+			[]string{"25/12/2020", "01/02/2021"},
+			[]time.Time{d(2020, 12, 25), d(2021, 2, 1)}},
+		{"mm/dd by default", false,
+			[]string{"02/01/2020", "03/01/2020"},
+			[]time.Time{d(2020, 2, 1), d(2020, 3, 1)}},
+		{"unpadded mm/dd", false,
+			[]string{"1/5/2020", "12/25/2020", "3/4/2021 13:30"},
+			[]time.Time{d(2020, 1, 5), d(2020, 12, 25), d(2021, 3, 4).Add(13*time.Hour + 30*time.Minute)}},
+		{"unpadded dd/mm established", false,
+			[]string{"25/1/2020", "1/5/2020"},
+			[]time.Time{d(2020, 1, 25), d(2020, 5, 1)}},
+		{"iso unaffected", true,
+			[]string{"2020-02-01", "2020-02-02"},
+			[]time.Time{d(2020, 2, 1), d(2020, 2, 2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewDateParser(time.UTC, tt.dayFirst)
+			for i, s := range tt.in {
+				got, err := p.Parse(s)
+				if err != nil {
+					t.Fatalf("%s: %v", s, err)
+				}
+				if !got.Equal(tt.want[i]) {
+					t.Errorf("%s: got %v, want %v", s, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDateParserContradiction(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+	}{
+		{"dd/mm then mm/dd", []string{"13/01/2020", "02/01/2020", "01/13/2020"}},
+		{"mm/dd then dd/mm", []string{"01/13/2020", "13/01/2020"}},
+		{"unpadded", []string{"1/13/2020", "13/1/2020"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewDateParser(time.UTC, false)
+			last := len(tt.in) - 1
+			for _, s := range tt.in[:last] {
+				if _, err := p.Parse(s); err != nil {
+					t.Fatalf("%s: %v", s, err)
+				}
+			}
+			if got, err := p.Parse(tt.in[last]); err == nil {
+				t.Errorf("%s: got %v, want a contradiction error", tt.in[last], got)
+			}
+		})
+	}
+}
+
+func TestDateParserStrict(t *testing.T) {
+	p := NewDateParser(time.UTC, true)
+	p.Strict = true
+	if _, err := p.Parse("01/01/2020"); err != nil {
+		t.Fatalf("day == month is not ambiguous: %v", err)
+	}
+	if _, err := p.Parse("02/01/2020"); err == nil {
+		t.Fatal("expected an ambiguity error")
+	}
+	if _, err := p.Parse("13/01/2020"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parse("02/01/2020"); err != nil {
+		t.Fatalf("order established: %v", err)
+	}
+}
+
+func TestDateParserExcelSerial(t *testing.T) {
+	p := NewDateParser(time.UTC, true)
+	if _, err := p.Parse("43831"); err == nil {
+		t.Fatal("bare numbers must not parse as dates by default")
+	}
+	p.ExcelSerial = true
+	got, err := p.Parse("43831.5")
+	if want := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC); err != nil || !got.Equal(want) {
+		t.Fatalf("got %v %v, want %v", got, err, want)
+	}
+	if v := TimeToExcelSerial(got); v != 43831.5 {
+		t.Fatalf("TimeToExcelSerial: %v", v)
+	}
+}
@@ -0,0 +1,258 @@
+package mmio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffOptions controls how DiffTables aligns and compares two tables
+type DiffOptions struct {
+	Key    []string // align rows on these columns; by row order if empty
+	AbsTol float64  // numeric cells are equal if |a-b| <= AbsTol
+	RelTol float64  // or if |a-b| <= RelTol*max(|a|,|b|)
+	Ignore []string // columns not compared
+}
+
+// CellDiff is a changed cell; Delta is b-a for numeric cells, NaN otherwise
+type CellDiff struct {
+	Row    string // key values (or row number when aligned by order)
+	Column string
+	A, B   string
+	Delta  float64
+}
+
+// DiffStats summarizes the differences of a numeric column over all aligned rows. A column
+// numeric on one side only is compared numerically, its text cells that do not read as numbers
+// being reported as changed. Cells that are missing or infinite on either side are only counted
+// in Changed, when they differ, and are left out of the other statistics.
+type DiffStats struct {
+	N       int // finite cells compared
+	Changed int // cells outside tolerance
+	MaxAbs  float64
+	MeanAbs float64
+	RMSE    float64
+}
+
+// TableDiff is the result of DiffTables
+type TableDiff struct {
+	Compared       int      // aligned rows
+	Added          []string // rows of b not in a
+	Removed        []string // rows of a not in b
+	ColumnsAdded   []string
+	ColumnsRemoved []string
+	Changed        []CellDiff
+	Stats          map[string]*DiffStats
+}
+
+// Equal returns true when no rows, columns or cells differ
+func (d *TableDiff) Equal() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.ColumnsAdded) == 0 &&
+		len(d.ColumnsRemoved) == 0 && len(d.Changed) == 0
+}
+
+// DiffCSV reads and compares two csv files with a single header line
+func DiffCSV(fpA, fpB string, opt DiffOptions) (*TableDiff, error) {
+	a, err := ReadCsvTable(fpA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ReadCsvTable(fpB)
+	if err != nil {
+		return nil, err
+	}
+	return DiffTables(a, b, opt)
+}
+
+// DiffTables aligns b to a (by key columns or row order) and compares the common columns
+func DiffTables(a, b *Table, opt DiffOptions) (*TableDiff, error) {
+	d := &TableDiff{Stats: make(map[string]*DiffStats)}
+	skip := make(map[string]bool, len(opt.Ignore)+len(opt.Key))
+	for _, c := range opt.Ignore {
+		skip[c] = true
+	}
+	for _, c := range opt.Key {
+		skip[c] = true
+	}
+
+	// align rows
+	var ia, ib []int
+	var labels []string
+	if len(opt.Key) > 0 {
+		ka, err := a.indices(opt.Key)
+		if err != nil {
+			return nil, fmt.Errorf("DiffTables a: %v", err)
+		}
+		kb, err := b.indices(opt.Key)
+		if err != nil {
+			return nil, fmt.Errorf("DiffTables b: %v", err)
+		}
+		label := func(t *Table, kk []int, i int) string {
+			return strings.ReplaceAll(rowKey(t.cols, kk, i), "\x00", ",")
+		}
+		ib0 := make(map[string]int, b.nrow)
+		for i := 0; i < b.nrow; i++ {
+			k := rowKey(b.cols, kb, i)
+			if _, ok := ib0[k]; ok {
+				return nil, fmt.Errorf("DiffTables b: duplicate key %s", label(b, kb, i))
+			}
+			ib0[k] = i
+		}
+		seen := make(map[string]bool, a.nrow)
+		for i := 0; i < a.nrow; i++ {
+			k := rowKey(a.cols, ka, i)
+			if seen[k] {
+				return nil, fmt.Errorf("DiffTables a: duplicate key %s", label(a, ka, i))
+			}
+			seen[k] = true
+			if j, ok := ib0[k]; ok {
+				ia, ib = append(ia, i), append(ib, j)
+				labels = append(labels, label(a, ka, i))
+			} else {
+				d.Removed = append(d.Removed, label(a, ka, i))
+			}
+		}
+		for i := 0; i < b.nrow; i++ {
+			if !seen[rowKey(b.cols, kb, i)] {
+				d.Added = append(d.Added, label(b, kb, i))
+			}
+		}
+	} else {
+		n := a.nrow
+		if b.nrow < n {
+			n = b.nrow
+		}
+		for i := 0; i < n; i++ {
+			ia, ib = append(ia, i), append(ib, i)
+			labels = append(labels, "row "+strconv.Itoa(i+1))
+		}
+		for i := n; i < a.nrow; i++ {
+			d.Removed = append(d.Removed, "row "+strconv.Itoa(i+1))
+		}
+		for i := n; i < b.nrow; i++ {
+			d.Added = append(d.Added, "row "+strconv.Itoa(i+1))
+		}
+	}
+	d.Compared = len(ia)
+
+	// compare columns
+	for _, h := range b.Head {
+		if a.Index(h) < 0 && !skip[h] {
+			d.ColumnsAdded = append(d.ColumnsAdded, h)
+		}
+	}
+	for ja, h := range a.Head {
+		if skip[h] {
+			continue
+		}
+		jb := b.Index(h)
+		if jb < 0 {
+			d.ColumnsRemoved = append(d.ColumnsRemoved, h)
+			continue
+		}
+		ca, cb := a.cols[ja], b.cols[jb]
+		if !ca.numeric() && !cb.numeric() {
+			for k := range ia {
+				if sa, sb := ca.key(ia[k]), cb.key(ib[k]); sa != sb {
+					d.Changed = append(d.Changed, CellDiff{labels[k], h, sa, sb, math.NaN()})
+				}
+			}
+			continue
+		}
+		// numeric on at least one side: text cells that read as numbers are compared with the tolerance
+		st, ssq := &DiffStats{}, 0.
+		for k := range ia {
+			va, oka := diffFloat(ca, ia[k])
+			vb, okb := diffFloat(cb, ib[k])
+			if !oka || !okb || math.IsNaN(va) || math.IsNaN(vb) {
+				if !oka || !okb || math.IsNaN(va) != math.IsNaN(vb) {
+					st.Changed++
+					d.Changed = append(d.Changed, CellDiff{labels[k], h, ca.key(ia[k]), cb.key(ib[k]), math.NaN()})
+				}
+				continue
+			}
+			if math.IsInf(va, 0) || math.IsInf(vb, 0) {
+				if va != vb {
+					st.Changed++
+					d.Changed = append(d.Changed, CellDiff{labels[k], h, ca.key(ia[k]), cb.key(ib[k]), vb - va})
+				}
+				continue
+			}
+			dv := vb - va
+			ad := math.Abs(dv)
+			st.N++
+			st.MeanAbs += ad
+			ssq += dv * dv
+			if ad > st.MaxAbs {
+				st.MaxAbs = ad
+			}
+			if ad > opt.AbsTol && ad > opt.RelTol*math.Max(math.Abs(va), math.Abs(vb)) {
+				st.Changed++
+				d.Changed = append(d.Changed, CellDiff{labels[k], h, ca.key(ia[k]), cb.key(ib[k]), dv})
+			}
+		}
+		if st.N > 0 {
+			st.MeanAbs /= float64(st.N)
+			st.RMSE = math.Sqrt(ssq / float64(st.N))
+		}
+		d.Stats[h] = st
+	}
+	return d, nil
+}
+
+// diffFloat returns the numeric value of a cell; for text columns, missing cells ("" or "NA") are
+// NaN and ok is false for text that does not read as a number
+func diffFloat(c tcol, i int) (v float64, ok bool) {
+	if c.numeric() {
+		return c.f[i], true
+	}
+	x := strings.TrimSpace(c.s[i])
+	if len(x) == 0 || x == "NA" {
+		return math.NaN(), true
+	}
+	v, err := strconv.ParseFloat(x, 64)
+	return v, err == nil
+}
+
+// Write prints a readable report of the differences, listing at most maxCells changed cells (0: all)
+func (d *TableDiff) Write(w io.Writer, maxCells int) {
+	fmt.Fprintf(w, "rows compared: %d, added: %d, removed: %d, cells changed: %d\n", d.Compared, len(d.Added), len(d.Removed), len(d.Changed))
+	if len(d.ColumnsAdded) > 0 {
+		fmt.Fprintf(w, "columns added: %s\n", strings.Join(d.ColumnsAdded, ", "))
+	}
+	if len(d.ColumnsRemoved) > 0 {
+		fmt.Fprintf(w, "columns removed: %s\n", strings.Join(d.ColumnsRemoved, ", "))
+	}
+	for _, r := range d.Added {
+		fmt.Fprintf(w, "+ %s\n", r)
+	}
+	for _, r := range d.Removed {
+		fmt.Fprintf(w, "- %s\n", r)
+	}
+	for i, c := range d.Changed {
+		if maxCells > 0 && i >= maxCells {
+			fmt.Fprintf(w, "  ... %d more\n", len(d.Changed)-maxCells)
+			break
+		}
+		if math.IsNaN(c.Delta) {
+			fmt.Fprintf(w, "~ %s [%s]: %s -> %s\n", c.Row, c.Column, c.A, c.B)
+		} else {
+			fmt.Fprintf(w, "~ %s [%s]: %s -> %s (%+g)\n", c.Row, c.Column, c.A, c.B, c.Delta)
+		}
+	}
+	if len(d.Stats) > 0 {
+		cols := make([]string, 0, len(d.Stats))
+		for c := range d.Stats {
+			cols = append(cols, c)
+		}
+		sort.Strings(cols)
+		fmt.Fprintf(w, "%-20s %10s %10s %12s %12s %12s\n", "column", "n", "changed", "max|d|", "mean|d|", "rmse")
+		for _, c := range cols {
+			s := d.Stats[c]
+			fmt.Fprintf(w, "%-20s %10d %10d %12.4g %12.4g %12.4g\n", c, s.N, s.Changed, s.MaxAbs, s.MeanAbs, s.RMSE)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mmio
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDiffTables(t *testing.T) {
+	tests := []struct {
+		name        string
+		a, b        string
+		opt         DiffOptions
+		wantChanged []string // row/column of each changed cell
+		wantN       int      // DiffStats.N of column v
+		wantMeanAbs float64
+	}{
+		{"equal", "id,v\n1,1.0\n2,2\n", "id,v\n1,1\n2,2.0\n", DiffOptions{}, nil, 2, 0},
+		{"tolerance", "id,v\n1,1\n2,2\n", "id,v\n1,1.05\n2,2.5\n", DiffOptions{AbsTol: 0.1}, []string{"row 2/v"}, 2, 0.275},
+		{"relative tolerance", "id,v\n1,100\n", "id,v\n1,101\n", DiffOptions{RelTol: 0.02}, nil, 1, 1},
+		{"by key", "id,v\n1,1\n2,2\n", "id,v\n2,2\n1,1\n3,3\n", DiffOptions{Key: []string{"id"}}, nil, 2, 0},
+		{"missing", "id,v\n1,NA\n2,\n3,1\n", "id,v\n1,\n2,5\n3,1\n", DiffOptions{}, []string{"row 2/v"}, 1, 0},
+		{"infinities", "id,v\n1,+Inf\n2,-Inf\n3,1\n", "id,v\n1,+Inf\n2,+Inf\n3,2\n", DiffOptions{}, []string{"row 2/v", "row 3/v"}, 1, 1},
+		{"text on one side", "id,v\n1,1\n2,2\n3,3\n", "id,v\n1,1.0001\n2,n/a\n3,\n", DiffOptions{AbsTol: 0.01}, []string{"row 2/v", "row 3/v"}, 1, 0.0001},
+		{"text both sides", "id,v\n1,a\n2,b\n", "id,v\n1,a\n2,c\n", DiffOptions{}, []string{"row 2/v"}, -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ReadCsvTableFrom(strings.NewReader(tt.a))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ReadCsvTableFrom(strings.NewReader(tt.b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := DiffTables(a, b, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range d.Changed {
+				if c.Column != "id" {
+					got = append(got, c.Row+"/"+c.Column)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.wantChanged, " ") {
+				t.Errorf("changed %v, want %v", got, tt.wantChanged)
+			}
+			st, ok := d.Stats["v"]
+			if tt.wantN < 0 {
+				if ok {
+					t.Errorf("stats for a text column: %+v", st)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("no stats for v")
+			}
+			if st.N != tt.wantN || math.IsNaN(st.MeanAbs) || math.Abs(st.MeanAbs-tt.wantMeanAbs) > 1e-9 {
+				t.Errorf("stats %+v, want N %d, MeanAbs %g", st, tt.wantN, tt.wantMeanAbs)
+			}
+		})
+	}
+}
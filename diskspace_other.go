@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || dragonfly || windows)
+
+package mmio
+
+import "errors"
+
+func diskSpace(path string) (DiskSpace, error) {
+	return DiskSpace{}, errors.ErrUnsupported
+}
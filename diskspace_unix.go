@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package mmio
+
+import "syscall"
+
+func diskSpace(path string) (DiskSpace, error) {
+	var s syscall.Statfs_t
+	if err := syscall.Statfs(path, &s); err != nil {
+		return DiskSpace{}, err
+	}
+	bs := int64(s.Bsize)
+	d := DiskSpace{Total: int64(s.Blocks) * bs, Free: int64(s.Bfree) * bs, Avail: int64(s.Bavail) * bs}
+	if d.Avail < 0 { // blocks reserved for root exceeded (BSD)
+		d.Avail = 0
+	}
+	return d, nil
+}
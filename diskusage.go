@@ -0,0 +1,173 @@
+package mmio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DirUsage is the space used by the files under a directory
+type DirUsage struct {
+	Path  string
+	Size  int64 // sum of file sizes (symbolic links are not followed)
+	Files int
+}
+
+// DirSize returns the total size and number of files under dir, recursively, walking
+// subdirectories in parallel (see DirSizes)
+func DirSize(dir string) (DirUsage, error) {
+	us, err := DirSizes(0, dir)
+	return us[0], err
+}
+
+// DirSizes computes DirSize of each of dirs, returned in the order given. Subdirectories are
+// walked in parallel by up to nworkers goroutines (nworkers < 1 uses GOMAXPROCS), so that a
+// single large directory also benefits. Entries that cannot be read are skipped rather than
+// aborting the walk: the usage of everything readable is returned along with their joined errors.
+func DirSizes(nworkers int, dirs ...string) ([]DirUsage, error) {
+	if nworkers < 1 {
+		nworkers = runtime.GOMAXPROCS(0)
+	}
+	w := duWalker{sem: make(chan struct{}, nworkers)}
+	cs := make([]duCounter, len(dirs))
+	for i, d := range dirs {
+		w.sem <- struct{}{}
+		w.wg.Add(1)
+		go func(d string, c *duCounter) {
+			defer w.done()
+			w.walk(d, c)
+		}(d, &cs[i])
+	}
+	w.wg.Wait()
+	us := make([]DirUsage, len(dirs))
+	for i, d := range dirs {
+		us[i] = DirUsage{Path: d, Size: cs[i].size.Load(), Files: int(cs[i].files.Load())}
+	}
+	return us, errors.Join(w.errs...)
+}
+
+type duCounter struct {
+	size, files atomic.Int64
+}
+
+// duWalker walks directory trees, handing subdirectories to another goroutine while sem has room
+type duWalker struct {
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+func (w *duWalker) done() {
+	<-w.sem
+	w.wg.Done()
+}
+
+func (w *duWalker) fail(fp string, err error) {
+	w.mu.Lock()
+	w.errs = append(w.errs, pathError("DirSize", fp, err))
+	w.mu.Unlock()
+}
+
+func (w *duWalker) walk(dir string, c *duCounter) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		w.fail(dir, err) // the entries read before the error are still counted
+	}
+	for _, de := range des {
+		fp := filepath.Join(dir, de.Name())
+		switch {
+		case de.IsDir():
+			select {
+			case w.sem <- struct{}{}:
+				w.wg.Add(1)
+				go func() {
+					defer w.done()
+					w.walk(fp, c)
+				}()
+			default:
+				w.walk(fp, c)
+			}
+		case de.Type().IsRegular(): // symbolic links are not followed
+			fi, err := de.Info()
+			if err != nil {
+				w.fail(fp, err)
+				continue
+			}
+			c.size.Add(fi.Size())
+			c.files.Add(1)
+		}
+	}
+}
+
+// LargestDirs returns the immediate subdirectories of root by decreasing size, at most n
+// of them (n < 1: all), e.g. to find the old run folders using the most space. As with
+// DirSizes, unreadable entries are skipped and reported in the returned error.
+func LargestDirs(root string, n, nworkers int) ([]DirUsage, error) {
+	des, err := os.ReadDir(root)
+	if err != nil {
+		return nil, pathError("LargestDirs", root, err)
+	}
+	var dirs []string
+	for _, de := range des {
+		if de.IsDir() {
+			dirs = append(dirs, filepath.Join(root, de.Name()))
+		}
+	}
+	us, err := DirSizes(nworkers, dirs...)
+	sort.SliceStable(us, func(i, j int) bool { return us[i].Size > us[j].Size })
+	if n > 0 && len(us) > n {
+		us = us[:n]
+	}
+	return us, err
+}
+
+// LargestFiles returns the files under root by decreasing size, at most n of them (n < 1: all)
+func LargestFiles(root string, n int) ([]FileEntry, error) {
+	es, err := ListFiles(root, ListOptions{Hidden: true, Sort: SortBySize, Reverse: true})
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 && len(es) > n {
+		es = es[:n]
+	}
+	return es, nil
+}
+
+// DiskSpace is the capacity of a filesystem in bytes
+type DiskSpace struct {
+	Total int64
+	Free  int64 // including blocks reserved for the superuser
+	Avail int64 // available to the current user
+}
+
+func (d DiskSpace) String() string {
+	return fmt.Sprintf("%s available of %s", HumanSize(d.Avail), HumanSize(d.Total))
+}
+
+// FreeSpace returns the capacity of the filesystem holding path (statfs; GetDiskFreeSpaceEx on
+// Windows). errors.ErrUnsupported is returned on other platforms.
+func FreeSpace(path string) (DiskSpace, error) {
+	d, err := diskSpace(path)
+	if err != nil {
+		return d, pathError("FreeSpace", path, err)
+	}
+	return d, nil
+}
+
+// CheckFreeSpace returns an error if fewer than need bytes are available on the filesystem holding path
+func CheckFreeSpace(path string, need int64) error {
+	d, err := FreeSpace(path)
+	if err != nil {
+		return err
+	}
+	if d.Avail < need {
+		return fmt.Errorf("CheckFreeSpace %s: %s needed, %s", path, HumanSize(need), d)
+	}
+	return nil
+}
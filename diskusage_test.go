@@ -0,0 +1,124 @@
+package mmio
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirSizes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]int{
+		"run1/a.bin":         100,
+		"run1/out/b.bin":     200,
+		"run1/out/sub/c.bin": 300,
+		"run2/d.bin":         50,
+		"run3/x/y/z/e.bin":   1000,
+		"run3/x/f.bin":       1,
+	}
+	for rel, n := range files {
+		fp := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(strings.Repeat("x", n)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir          string
+		size         int64
+		files        int
+		wantNotExist bool
+	}{
+		{"run1", 600, 3, false},
+		{"run2", 50, 1, false},
+		{"run3", 1001, 2, false},
+		{"empty", 0, 0, false},
+		{"missing", 0, 0, true},
+	}
+	var dirs []string
+	for _, tt := range tests {
+		dirs = append(dirs, filepath.Join(root, tt.dir))
+	}
+	for _, nworkers := range []int{1, 2, 0} {
+		us, err := DirSizes(nworkers, dirs...)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("nworkers %d: error %v, want the missing directory reported", nworkers, err)
+		}
+		for i, tt := range tests {
+			if us[i].Path != dirs[i] || us[i].Size != tt.size || us[i].Files != tt.files {
+				t.Errorf("nworkers %d: %s = %+v, want %d bytes in %d files", nworkers, tt.dir, us[i], tt.size, tt.files)
+			}
+		}
+	}
+
+	us, err := LargestDirs(root, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(us) != 2 || filepath.Base(us[0].Path) != "run3" || filepath.Base(us[1].Path) != "run1" {
+		t.Errorf("LargestDirs = %+v, want run3, run1", us)
+	}
+}
+
+func TestDirSizesUnreadable(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	root := t.TempDir()
+	for _, fp := range []string{"ok/a.bin", "locked/b.bin"} {
+		fp = filepath.Join(root, filepath.FromSlash(fp))
+		os.MkdirAll(filepath.Dir(fp), 0755)
+		if err := os.WriteFile(fp, []byte("abc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	locked := filepath.Join(root, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Skip(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	u, err := DirSize(root)
+	if err == nil {
+		t.Error("unreadable directory not reported")
+	}
+	if u.Size != 3 || u.Files != 1 {
+		t.Errorf("DirSize = %+v, want the readable file counted", u)
+	}
+}
+
+func TestFreeSpace(t *testing.T) {
+	d, err := FreeSpace(t.TempDir())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if d.Total <= 0 || d.Avail < 0 || d.Avail > d.Free || d.Free > d.Total {
+		t.Errorf("FreeSpace = %+v", d)
+	}
+	tests := []struct {
+		need    int64
+		wantErr bool
+	}{
+		{0, false},
+		{d.Total + 1, true},
+	}
+	for _, tt := range tests {
+		if err := CheckFreeSpace(t.TempDir(), tt.need); (err != nil) != tt.wantErr {
+			t.Errorf("CheckFreeSpace(%s): %v", HumanSize(tt.need), err)
+		}
+	}
+	if _, err := FreeSpace(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing path: %v", err)
+	}
+}
@@ -1,52 +1,98 @@
 package mmio
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
+// pathError wraps err with the mmio operation and the path it failed on. The path is
+// retrievable with errors.As(err, *fs.PathError) and the cause with errors.Is.
+func pathError(op, path string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) && pe.Path == path {
+		err = pe.Err
+	}
+	return &fs.PathError{Op: "mmio." + op, Path: path, Err: err}
+}
+
 // DeleteFile deletes the specified file or directory
 func DeleteFile(fp string) {
-	if DirExists(fp) {
-		if err := os.RemoveAll(fp); err != nil {
-			log.Fatalf("files.go DeleteFile error 2: %v", err)
-		}
-	} else if _, ok := FileExists(fp); ok {
-		if err := os.Remove(fp); err != nil {
-			log.Fatalf("files.go DeleteFile error 1: %v", err)
-		}
+	if err := DeleteFileE(fp); err != nil {
+		log.Fatalf("files.go DeleteFile error: %v", err)
 	}
 }
 
+// DeleteFileE deletes the specified file or directory; a missing path is not an error
+func DeleteFileE(fp string) error {
+	fi, err := os.Lstat(fp)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return pathError("DeleteFile", fp, err)
+	}
+	if fi.IsDir() {
+		err = os.RemoveAll(fp)
+	} else {
+		err = os.Remove(fp)
+	}
+	if err != nil {
+		return pathError("DeleteFile", fp, err)
+	}
+	return nil
+}
+
 // DeleteAllInDirectory deletes all files of a given extension in a specified directory
 // exension format: ".***"
 func DeleteAllInDirectory(dir, ext string) {
+	if err := DeleteAllInDirectoryE(dir, ext); err != nil {
+		panic(err)
+	}
+}
+
+// DeleteAllInDirectoryE deletes all files of a given extension in a specified directory,
+// attempting every file and returning the joined errors
+func DeleteAllInDirectoryE(dir, ext string) error {
 	fps, err := FileListExt(dir, ext)
 	if err != nil {
-		panic(err)
+		return pathError("DeleteAllInDirectory", dir, err)
 	}
+	var errs []error
 	for _, fp := range fps {
-		DeleteFile(fp)
+		if err := DeleteFileE(fp); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
 }
 
 // DeleteDir deletes a directory
 func DeleteDir(dir string) {
-	err := os.RemoveAll(dir)
-	if err != nil {
+	if err := DeleteDirE(dir); err != nil {
 		log.Fatal(err)
 	}
 }
 
-// DeleteAllSubdirectories deletes all subdirectories within a specified directory
+// DeleteDirE deletes a directory and its contents
+func DeleteDirE(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return pathError("DeleteDir", dir, err)
+	}
+	return nil
+}
+
+// DeleteAllSubdirectories deletes all subdirectories within a specified directory;
+// subdirectories that cannot be removed are skipped (see DeleteAllSubdirectoriesE)
 func DeleteAllSubdirectories(dir string) {
 	files, err := os.ReadDir(dir)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal(pathError("DeleteAllSubdirectories", dir, err))
 	}
 	for _, f := range files {
 		if f.IsDir() {
@@ -55,6 +101,24 @@ func DeleteAllSubdirectories(dir string) {
 	}
 }
 
+// DeleteAllSubdirectoriesE deletes all subdirectories within a specified directory,
+// attempting every subdirectory and returning the joined errors
+func DeleteAllSubdirectoriesE(dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return pathError("DeleteAllSubdirectories", dir, err)
+	}
+	var errs []error
+	for _, f := range files {
+		if f.IsDir() {
+			if err := DeleteDirE(filepath.Join(dir, f.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // FileExists checks if a file exists and returns its size
 func FileExists(path string) (int64, bool) {
 	if fi, err := os.Stat(path); err == nil {
@@ -67,7 +131,20 @@ func FileExists(path string) (int64, bool) {
 	}
 }
 
-// DirExists checks if a directory exists
+// FileExistsE checks if a file exists and returns its size; errors other than
+// the path not existing (e.g., permission denied) are returned
+func FileExistsE(path string) (int64, bool, error) {
+	fi, err := os.Stat(path)
+	if err == nil {
+		return fi.Size(), true, nil
+	} else if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	return 0, false, pathError("FileExists", path, err)
+}
+
+// DirExists checks if a directory exists. It is only false when path does not exist: it is
+// also true for files and when path cannot be checked, e.g., permission denied (see DirExistsE).
 func DirExists(path string) bool {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return false
@@ -75,6 +152,17 @@ func DirExists(path string) bool {
 	return true
 }
 
+// DirExistsE checks if a directory exists; errors other than the path not existing are returned
+func DirExistsE(path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if err == nil {
+		return fi.IsDir(), nil
+	} else if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, pathError("DirExists", path, err)
+}
+
 // IsDir check if the entered path is a directory
 func IsDir(path string) bool {
 	fi, err := os.Stat(path)
@@ -85,20 +173,35 @@ func IsDir(path string) bool {
 	return m.IsDir()
 }
 
-// MakeDir checks if directory exists, if not, creates it
+// MakeDir checks if directory exists, if not, creates it. A path that exists but is not a
+// directory, or cannot be checked, is left as it is; use MakeDirE to have these reported.
 func MakeDir(path string) string {
-	if !DirExists(path) {
-		// if err := os.Mkdir(path, os.ModeDir); err != nil {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
 		if err := os.MkdirAll(path, os.ModePerm); err != nil {
-			log.Fatal(err)
+			log.Fatal(pathError("MakeDir", path, err))
 		}
 	}
 	return path
 }
 
+// MakeDirE checks if directory exists, if not, creates it (with any missing parents)
+func MakeDirE(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err == nil {
+		if !fi.IsDir() {
+			return path, pathError("MakeDir", path, syscall.ENOTDIR)
+		}
+		return path, nil
+	}
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return path, pathError("MakeDir", path, err)
+	}
+	return path, nil
+}
+
 // CleanDir adds a "/" at end if it does not exist
 func cleanDir(dir string) string {
-	if dir[len(dir)-1:] != "/" {
+	if len(dir) == 0 || dir[len(dir)-1:] != "/" {
 		dir += "/"
 	}
 	return dir
@@ -128,7 +231,7 @@ func FileList(path string) ([]string, error) {
 			return nil
 		})
 	if err != nil {
-		return nil, err
+		return nil, pathError("FileList", path, err)
 	}
 	return s, nil
 }
@@ -140,7 +243,7 @@ func FileListExt(dir, ext string) ([]string, error) {
 	dir = cleanDir(dir)
 	files, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, err
+		return nil, pathError("FileListExt", dir, err)
 	}
 
 	var flst []string
@@ -167,7 +270,7 @@ func DirList(root string) ([]string, error) {
 			return nil
 		})
 	if err != nil {
-		return nil, err
+		return nil, pathError("DirList", root, err)
 	}
 	return s, nil
 }
@@ -185,94 +288,125 @@ func RemoveExtension(fp string) string {
 
 // GetFileDir returns the directory that contains the given filepath
 func GetFileDir(fp string) string {
-	if fp[len(fp)-1:] == "/" {
+	if len(fp) > 1 && fp[len(fp)-1:] == "/" {
 		return filepath.Dir(fp[:len(fp)-1])
 	}
 	return filepath.Dir(fp)
 }
 
-// FileRename renames a file
+// FileRename renames a file, replacing any existing newName: overwrite is ignored, use
+// FileRenameE to have it honoured
 func FileRename(oldName, newName string, overwrite bool) {
 	if _, ok := FileExists(oldName); ok {
-		err := os.Rename(oldName, newName)
-		if err != nil {
+		if err := FileRenameE(oldName, newName, true); err != nil {
 			log.Fatal(err)
 		}
 	}
 }
 
-// CopyFile copies a file (modified from https://opensource.com/article/18/6/copying-files-go)
+// FileRenameE renames a file. When overwrite is false and newName exists, an error
+// satisfying errors.Is(err, fs.ErrExist) is returned.
+func FileRenameE(oldName, newName string, overwrite bool) error {
+	if _, err := os.Lstat(oldName); err != nil {
+		return pathError("FileRename", oldName, err)
+	}
+	if !overwrite {
+		if _, err := os.Lstat(newName); err == nil {
+			return pathError("FileRename", newName, fs.ErrExist)
+		} else if !os.IsNotExist(err) {
+			return pathError("FileRename", newName, err)
+		}
+	}
+	if err := os.Rename(oldName, newName); err != nil {
+		return pathError("FileRename", oldName, err)
+	}
+	return nil
+}
+
+// CopyFile copies a file (modified from https://opensource.com/article/18/6/copying-files-go).
+// A missing src copies nothing and is not an error; use CopyFileE to have it reported.
 func CopyFile(src, dst string) (int64, error) {
 	if _, ok := FileExists(src); !ok {
 		return 0, nil
 	}
+	return CopyFileE(src, dst)
+}
 
+// CopyFileE copies a file; a missing or non-regular src is an error
+func CopyFileE(src, dst string) (int64, error) {
 	sourceFileStat, err := os.Stat(src)
 	if err != nil {
-		return 0, err
+		return 0, pathError("CopyFile", src, err)
 	}
 
 	if !sourceFileStat.Mode().IsRegular() {
-		return 0, fmt.Errorf("%s is not a regular file", src)
+		return 0, pathError("CopyFile", src, fmt.Errorf("not a regular file"))
 	}
 
 	source, err := os.Open(src)
 	if err != nil {
-		return 0, err
+		return 0, pathError("CopyFile", src, err)
 	}
 	defer source.Close()
 
 	destination, err := os.Create(dst)
 	if err != nil {
-		return 0, err
+		return 0, pathError("CopyFile", dst, err)
 	}
-	defer destination.Close()
 	nBytes, err := io.Copy(destination, source)
-	return nBytes, err
+	if cerr := destination.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nBytes, pathError("CopyFile", dst, err)
+	}
+	return nBytes, nil
 }
 
-// MoveFile moves a file (https://stackoverflow.com/questions/50740902/move-a-file-to-a-different-drive-with-go)
+// MoveFile moves a file, renaming it when possible and otherwise (across devices) copying it,
+// with its mode bits and modification time, then deleting the original
 func MoveFile(sourcePath, destPath string) error {
-	inputFile, err := os.Open(sourcePath)
+	fi, err := os.Lstat(sourcePath)
 	if err != nil {
-		return fmt.Errorf("Couldn't open source file: %s", err)
+		return pathError("MoveFile", sourcePath, err)
 	}
-	outputFile, err := os.Create(destPath)
-	if err != nil {
-		inputFile.Close()
-		return fmt.Errorf("Couldn't open dest file: %s", err)
+	if fi.IsDir() {
+		return pathError("MoveFile", sourcePath, fmt.Errorf("is a directory (use MoveDir)"))
 	}
-	defer outputFile.Close()
-	_, err = io.Copy(outputFile, inputFile)
-	inputFile.Close()
-	if err != nil {
-		return fmt.Errorf("Writing to output file failed: %s", err)
-	}
-	// The copy was successful, so now delete the original file
-	err = os.Remove(sourcePath)
-	if err != nil {
-		return fmt.Errorf("Failed removing original file: %s", err)
+	if err := moveEntry(sourcePath, destPath, fi); err != nil {
+		return pathError("MoveFile", destPath, err)
 	}
 	return nil
 }
 
+// CreateEmpty creates (or truncates) an empty file
 func CreateEmpty(filepath string) error {
 	emptyFile, err := os.Create(filepath)
 	if err != nil {
-		return err
+		return pathError("CreateEmpty", filepath, err)
 	}
-	emptyFile.Close()
-	return nil
+	return emptyFile.Close()
 }
 
+// RelativeFileCheck returns searchfp if it exists, otherwise searchfp relative to the directory of rootfp
 func RelativeFileCheck(rootfp, searchfp string) string {
-	if _, ok := FileExists(searchfp); !ok {
-		rfp := GetFileDir(rootfp) + "/" + searchfp
-		if _, ok := FileExists(rfp); ok {
-			return rfp
-		} else {
-			panic(searchfp + " cannot be found")
-		}
+	fp, err := RelativeFileCheckE(rootfp, searchfp)
+	if err != nil {
+		panic(err)
+	}
+	return fp
+}
+
+// RelativeFileCheckE returns searchfp if it exists, otherwise searchfp relative to the directory
+// of rootfp; the error lists both locations tried. Paths are taken literally: see Resolver for
+// environment variable expansion and more search locations.
+func RelativeFileCheckE(rootfp, searchfp string) (string, error) {
+	if _, ok := FileExists(searchfp); ok {
+		return searchfp, nil
+	}
+	rfp := GetFileDir(rootfp) + "/" + searchfp
+	if _, ok := FileExists(rfp); ok {
+		return rfp, nil
 	}
-	return searchfp
+	return "", pathError("RelativeFileCheck", searchfp, fmt.Errorf("%w (also tried %s)", fs.ErrNotExist, rfp))
 }
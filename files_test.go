@@ -0,0 +1,136 @@
+package mmio
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyFileMissing(t *testing.T) {
+	d := t.TempDir()
+	src := filepath.Join(d, "missing")
+	if n, err := CopyFile(src, filepath.Join(d, "dst")); n != 0 || err != nil {
+		t.Fatalf("CopyFile: got %d, %v, want 0, nil", n, err)
+	}
+	_, err := CopyFileE(src, filepath.Join(d, "dst"))
+	var pe *fs.PathError
+	if !errors.Is(err, fs.ErrNotExist) || !errors.As(err, &pe) || pe.Path != src {
+		t.Fatalf("got %v, want a path error on %s", err, src)
+	}
+	if _, ok := FileExists(filepath.Join(d, "dst")); ok {
+		t.Fatal("destination created")
+	}
+}
+
+func TestDirExistsE(t *testing.T) {
+	d := t.TempDir()
+	fp := filepath.Join(d, "f")
+	os.WriteFile(fp, nil, 0644)
+	tests := []struct {
+		path       string
+		want       bool
+		wantLegacy bool // DirExists is true for any existing path
+	}{
+		{d, true, true},
+		{fp, false, true},
+		{filepath.Join(d, "missing"), false, false},
+	}
+	for _, tt := range tests {
+		got, err := DirExistsE(tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %v %v, want %v", tt.path, got, err, tt.want)
+		}
+		if got := DirExists(tt.path); got != tt.wantLegacy {
+			t.Errorf("DirExists(%s) = %v, want %v", tt.path, got, tt.wantLegacy)
+		}
+	}
+}
+
+func TestMakeDir(t *testing.T) {
+	d := t.TempDir()
+	p := filepath.Join(d, "a", "b")
+	if MakeDir(p) != p || !DirExists(p) {
+		t.Fatal("not created")
+	}
+	fp := filepath.Join(d, "f")
+	os.WriteFile(fp, nil, 0644)
+	MakeDir(fp) // left as is
+	if _, err := MakeDirE(fp); err == nil {
+		t.Fatal("MakeDirE on a file: expected an error")
+	}
+}
+
+func TestFileRename(t *testing.T) {
+	tests := []struct {
+		name      string
+		exists    bool
+		overwrite bool
+		want      string // content of the new file
+		wantOld   bool   // old file left in place
+	}{
+		{"new", false, false, "old", false},
+		{"existing, overwrite", true, true, "old", false},
+		{"existing, no overwrite", true, false, "new", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := t.TempDir()
+			oldfp, newfp := filepath.Join(d, "a"), filepath.Join(d, "b")
+			os.WriteFile(oldfp, []byte("old"), 0644)
+			if tt.exists {
+				os.WriteFile(newfp, []byte("new"), 0644)
+			}
+			err := FileRenameE(oldfp, newfp, tt.overwrite)
+			if got := errors.Is(err, fs.ErrExist); got != tt.wantOld {
+				t.Errorf("FileRenameE: %v", err)
+			}
+			if b, _ := os.ReadFile(newfp); string(b) != tt.want {
+				t.Errorf("new file holds %q, want %q", b, tt.want)
+			}
+			if _, ok := FileExists(oldfp); ok != tt.wantOld {
+				t.Errorf("old file left: %v, want %v", ok, tt.wantOld)
+			}
+
+			os.WriteFile(oldfp, []byte("old"), 0644)
+			FileRename(oldfp, newfp, tt.overwrite) // always renames
+			if b, _ := os.ReadFile(newfp); string(b) != "old" {
+				t.Errorf("FileRename: new file holds %q, want %q", b, "old")
+			}
+		})
+	}
+}
+
+func TestDeleteAllSubdirectories(t *testing.T) {
+	d := t.TempDir()
+	writeTree(t, d, map[string]string{"a/x": "x", "b/c/y": "y", "f": "f"})
+	if err := DeleteAllSubdirectoriesE(d); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTree(t, d); len(got) != 1 || got["f"] != "f" {
+		t.Errorf("left %v, want only f", got)
+	}
+	if err := DeleteAllSubdirectoriesE(filepath.Join(d, "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing dir: %v", err)
+	}
+}
+
+func TestListErrorsWrapped(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	tests := []struct {
+		name string
+		list func(string) ([]string, error)
+	}{
+		{"FileList", FileList},
+		{"FileListExt", func(p string) ([]string, error) { return FileListExt(p, ".txt") }},
+		{"DirList", DirList},
+	}
+	for _, tt := range tests {
+		_, err := tt.list(missing)
+		var pe *fs.PathError
+		if !errors.Is(err, fs.ErrNotExist) || !errors.As(err, &pe) || pe.Op != "mmio."+tt.name {
+			t.Errorf("%s: got %v, want a mmio.%s path error", tt.name, err, tt.name)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package mmio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FixedType is the type of a fixed-width column, after the Fortran edit descriptors
+type FixedType byte
+
+const (
+	FixedInt    FixedType = 'I'
+	FixedFloat  FixedType = 'F'
+	FixedExp    FixedType = 'E'
+	FixedDouble FixedType = 'D' // as E, written with a D exponent letter
+	FixedString FixedType = 'A'
+)
+
+// FixedColumn describes a fixed-width column
+type FixedColumn struct {
+	Name  string
+	Start int // 0-based character offset
+	Width int
+	Type  FixedType
+	Dec   int // decimals written for F, E and D columns; read fields without a decimal point are scaled by 10^-Dec (Fortran implied decimal)
+}
+
+// ParseFortranFormat converts a Fortran-style format string, e.g. "(I5,2X,3F8.2,A10,E12.4)",
+// into column specifications. Supported descriptors are Iw, Fw.d, Ew.d, Dw.d, Gw.d, Aw and nX,
+// with repeat counts and parenthesized groups; columns are named c1, c2, ...
+func ParseFortranFormat(format string) ([]FixedColumn, error) {
+	f := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(format), " ", ""))
+	if strings.HasPrefix(f, "(") && strings.HasSuffix(f, ")") {
+		f = f[1 : len(f)-1]
+	}
+	var cols []FixedColumn
+	pos := 0
+	if err := parseFortranItems(f, &cols, &pos); err != nil {
+		return nil, fmt.Errorf("ParseFortranFormat %q: %v", format, err)
+	}
+	for i := range cols {
+		cols[i].Name = "c" + strconv.Itoa(i+1)
+	}
+	return cols, nil
+}
+
+func parseFortranItems(f string, cols *[]FixedColumn, pos *int) error {
+	for _, it := range splitFortranItems(f) {
+		if len(it) == 0 {
+			continue
+		}
+		n, k := 1, 0
+		for k < len(it) && it[k] >= '0' && it[k] <= '9' {
+			k++
+		}
+		if k > 0 {
+			n, _ = strconv.Atoi(it[:k])
+		}
+		d := it[k:]
+		if len(d) == 0 {
+			return fmt.Errorf("invalid item %q", it)
+		}
+		if d[0] == '(' {
+			if d[len(d)-1] != ')' {
+				return fmt.Errorf("unbalanced group %q", it)
+			}
+			for r := 0; r < n; r++ {
+				if err := parseFortranItems(d[1:len(d)-1], cols, pos); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if d == "X" {
+			*pos += n
+			continue
+		}
+		c := FixedColumn{}
+		switch d[0] {
+		case 'I':
+			c.Type = FixedInt
+		case 'F':
+			c.Type = FixedFloat
+		case 'E', 'G':
+			c.Type = FixedExp
+		case 'D':
+			c.Type = FixedDouble
+		case 'A':
+			c.Type = FixedString
+		default:
+			return fmt.Errorf("unsupported descriptor %q", it)
+		}
+		wd := strings.SplitN(d[1:], ".", 2)
+		w, err := strconv.Atoi(wd[0])
+		if err != nil || w < 1 {
+			return fmt.Errorf("invalid width in %q", it)
+		}
+		c.Width = w
+		if len(wd) == 2 {
+			if c.Dec, err = strconv.Atoi(wd[1]); err != nil {
+				return fmt.Errorf("invalid decimals in %q", it)
+			}
+		}
+		for r := 0; r < n; r++ {
+			c.Start = *pos
+			*cols = append(*cols, c)
+			*pos += c.Width
+		}
+	}
+	return nil
+}
+
+// splitFortranItems splits on commas outside of parentheses
+func splitFortranItems(f string) []string {
+	var a []string
+	lvl, b := 0, 0
+	for i, c := range f {
+		switch c {
+		case '(':
+			lvl++
+		case ')':
+			lvl--
+		case ',':
+			if lvl == 0 {
+				a = append(a, f[b:i])
+				b = i + 1
+			}
+		}
+	}
+	return append(a, f[b:])
+}
+
+// ReadFixedWidth reads a fixed-width text file into a Table, skipping nHeaderLines lines.
+// Numeric columns with blank fields are returned as NaN.
+func ReadFixedWidth(fp string, spec []FixedColumn, nHeaderLines int) (*Table, error) {
+	file, err := openRead(fp)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFixedWidth: %v", err)
+	}
+	defer file.Close()
+	t, err := readFixedWidth(file, spec, nHeaderLines)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFixedWidth %s: %v", fp, err)
+	}
+	return t, nil
+}
+
+// ReadFixedWidthFrom reads fixed-width text from r into a Table, see ReadFixedWidth
+func ReadFixedWidthFrom(r io.Reader, spec []FixedColumn, nHeaderLines int) (*Table, error) {
+	t, err := readFixedWidth(r, spec, nHeaderLines)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFixedWidth: %v", err)
+	}
+	return t, nil
+}
+
+func readFixedWidth(file io.Reader, spec []FixedColumn, nHeaderLines int) (*Table, error) {
+	fs := make([][]float64, len(spec))
+	ss := make([][]string, len(spec))
+	sc, ln := bufio.NewScanner(file), 0
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		ln++
+		if ln <= nHeaderLines {
+			continue
+		}
+		line := strings.TrimRight(sc.Text(), "\r")
+		for j, c := range spec {
+			s := fixedField(line, c)
+			if c.Type == FixedString {
+				ss[j] = append(ss[j], s)
+				continue
+			}
+			v, err := parseFixedNumber(s, c)
+			if err != nil {
+				return nil, fmt.Errorf("line %d column %d (%s): %v", ln, j+1, c.Name, err)
+			}
+			fs[j] = append(fs[j], v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	t := NewTable()
+	for j, c := range spec {
+		var err error
+		nam := c.Name
+		if len(nam) == 0 {
+			nam = "c" + strconv.Itoa(j+1)
+		}
+		if c.Type == FixedString {
+			if ss[j] == nil {
+				ss[j] = []string{}
+			}
+			err = t.AddStrings(nam, ss[j])
+		} else {
+			if fs[j] == nil {
+				fs[j] = []float64{}
+			}
+			err = t.AddFloats(nam, fs[j])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func fixedField(line string, c FixedColumn) string {
+	if c.Start >= len(line) {
+		return ""
+	}
+	e := c.Start + c.Width
+	if e > len(line) {
+		e = len(line)
+	}
+	return strings.TrimSpace(line[c.Start:e])
+}
+
+func parseFixedNumber(s string, c FixedColumn) (float64, error) {
+	if len(s) == 0 {
+		return math.NaN(), nil
+	}
+	if c.Type == FixedInt {
+		i, err := strconv.Atoi(s)
+		return float64(i), err
+	}
+	s = strings.NewReplacer("D", "E", "d", "E").Replace(s)
+	if i := strings.LastIndexAny(s, "+-"); i > 0 && s[i-1] != 'E' && s[i-1] != 'e' {
+		s = s[:i] + "E" + s[i:] // Fortran exponent above 99 written without its letter, e.g. 0.1235+100
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if c.Dec > 0 && !strings.ContainsAny(s, ".eE") {
+		v /= math.Pow10(c.Dec)
+	}
+	return v, nil
+}
+
+// FormatFixedWidth formats one line of values, right-aligned and padded to the column widths.
+// As in Fortran, numbers too wide for their column are written as asterisks, strings are
+// truncated to their leftmost characters, and E and D columns are written 0.ddddE+ee.
+// Columns may be given in any order, but must not overlap.
+func FormatFixedWidth(spec []FixedColumn, values ...interface{}) (string, error) {
+	if len(values) != len(spec) {
+		return "", fmt.Errorf("FormatFixedWidth: %d values given for %d columns", len(values), len(spec))
+	}
+	n := 0
+	for _, c := range spec {
+		if c.Start+c.Width > n {
+			n = c.Start + c.Width
+		}
+	}
+	b, used := []byte(strings.Repeat(" ", n)), make([]bool, n)
+	for j, c := range spec {
+		for k := c.Start; k < c.Start+c.Width; k++ {
+			if used[k] {
+				return "", fmt.Errorf("FormatFixedWidth column %d (%s) overlaps another column at character %d", j+1, c.Name, k+1)
+			}
+			used[k] = true
+		}
+		s, err := formatFixedValue(c, values[j])
+		if err != nil {
+			return "", fmt.Errorf("FormatFixedWidth column %d (%s): %v", j+1, c.Name, err)
+		}
+		if len(s) > c.Width {
+			if c.Type == FixedString {
+				s = s[:c.Width]
+			} else {
+				s = strings.Repeat("*", c.Width)
+			}
+		}
+		copy(b[c.Start+c.Width-len(s):], s)
+	}
+	return string(b), nil
+}
+
+func formatFixedValue(c FixedColumn, v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	if c.Type == FixedString {
+		return fmt.Sprint(v), nil
+	}
+	var f float64
+	switch x := v.(type) {
+	case float64:
+		f = x
+	case float32:
+		f = float64(x)
+	case int:
+		f = float64(x)
+	case int32:
+		f = float64(x)
+	case int64:
+		f = float64(x)
+	case string:
+		return x, nil
+	default:
+		return "", fmt.Errorf("unsupported type %T", v)
+	}
+	if math.IsNaN(f) {
+		return "", nil
+	}
+	switch c.Type {
+	case FixedInt:
+		return strconv.FormatInt(int64(math.Round(f)), 10), nil
+	case FixedExp:
+		return fortranExp(f, c.Dec, c.Width, 'E'), nil
+	case FixedDouble:
+		return fortranExp(f, c.Dec, c.Width, 'D'), nil
+	}
+	return strconv.FormatFloat(f, 'f', c.Dec, 64), nil
+}
+
+// fortranExp formats f as Fortran's Ew.d/Dw.d edit descriptors do: a 0.ddd mantissa of d digits
+// and a two-digit exponent, e.g. 12.345 as 0.1235E+02. Exponents beyond 99 drop the letter
+// (0.1235+100), and the leading zero is dropped when the field would not fit w otherwise.
+func fortranExp(f float64, d, w int, letter byte) string {
+	if math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', 0, 64)
+	}
+	if d < 1 {
+		d = 1
+	}
+	sign, e := "", 0
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	m := strings.Repeat("0", d)
+	if f != 0 {
+		s := strconv.FormatFloat(f, 'e', d-1, 64) // d.ddde±xx: d significant digits, rounded
+		i := strings.IndexByte(s, 'e')
+		m = strings.Replace(s[:i], ".", "", 1)
+		x, _ := strconv.Atoi(s[i+1:])
+		e = x + 1
+	}
+	es := "+"
+	if e < 0 {
+		es, e = "-", -e
+	}
+	var x string
+	if e > 99 {
+		x = fmt.Sprintf("%s%03d", es, e)
+	} else {
+		x = fmt.Sprintf("%c%s%02d", letter, es, e)
+	}
+	if o := sign + "0." + m + x; len(o) <= w {
+		return o
+	}
+	return sign + "." + m + x
+}
+
+// WriteFixedWidth writes a Table as a fixed-width text file; spec columns are matched to
+// table columns by name and an optional header line is written first
+func WriteFixedWidth(fp, header string, spec []FixedColumn, t *Table) error {
+	return writeOutput(fp, false, func(w io.Writer) error { return WriteFixedWidthTo(w, header, spec, t) })
+}
+
+// WriteFixedWidthTo writes a Table as fixed-width text to wr, see WriteFixedWidth
+func WriteFixedWidthTo(wr io.Writer, header string, spec []FixedColumn, t *Table) error {
+	cols := make([]int, len(spec))
+	for j, c := range spec {
+		if cols[j] = t.Index(c.Name); cols[j] < 0 {
+			return fmt.Errorf("WriteFixedWidth: column %s not found", c.Name)
+		}
+	}
+	w := NewTXTwriterTo(wr)
+	if len(header) > 0 {
+		if err := w.WriteLine(header); err != nil {
+			return err
+		}
+	}
+	vals := make([]interface{}, len(spec))
+	for i := 0; i < t.NRows(); i++ {
+		for j, k := range cols {
+			if tc := t.cols[k]; tc.numeric() {
+				vals[j] = tc.f[i]
+			} else {
+				vals[j] = tc.s[i]
+			}
+		}
+		s, err := FormatFixedWidth(spec, vals...)
+		if err != nil {
+			return fmt.Errorf("WriteFixedWidth row %d: %v", i+1, err)
+		}
+		if err := w.WriteLine(s); err != nil {
+			return err
+		}
+	}
+	return w.CloseE()
+}
@@ -0,0 +1,114 @@
+package mmio
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFormatFixedWidthFortran(t *testing.T) {
+	tests := []struct {
+		format string
+		value  interface{}
+		want   string
+	}{
+		{"(E12.4)", 12.345, "  0.1235E+02"},
+		{"(E12.4)", -0.00012346, " -0.1235E-03"},
+		{"(E10.4)", -12.345, "-.1235E+02"},
+		{"(E12.4)", 0., "  0.0000E+00"},
+		{"(E12.4)", 9.99996, "  0.1000E+02"},
+		{"(E12.4)", 1.2345e120, "  0.1235+121"},
+		{"(D12.4)", 12.345, "  0.1235D+02"},
+		{"(E8.4)", 12.345, "********"},
+		{"(F8.2)", -3.14159, "   -3.14"},
+		{"(F4.2)", 123.4, "****"},
+		{"(I5)", 42, "   42"},
+		{"(I2)", 123, "**"},
+		{"(A6)", "abc", "   abc"},
+		{"(A6)", "abcdefgh", "abcdef"},
+		{"(F8.2)", math.NaN(), "        "},
+	}
+	for _, tt := range tests {
+		spec, err := ParseFortranFormat(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := FormatFixedWidth(spec, tt.value)
+		if err != nil {
+			t.Fatalf("%s %v: %v", tt.format, tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s %v: got %q, want %q", tt.format, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFixedWidthSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    []FixedColumn
+		want    string
+		wantErr bool
+	}{
+		{"sorted", []FixedColumn{{"a", 0, 3, FixedInt, 0}, {"b", 5, 3, FixedString, 0}}, "  1  abc", false},
+		{"unsorted", []FixedColumn{{"b", 5, 3, FixedString, 0}, {"a", 0, 3, FixedInt, 0}}, "  1  abc", false},
+		{"overlapping", []FixedColumn{{"a", 0, 3, FixedInt, 0}, {"b", 2, 3, FixedString, 0}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vals := map[string]interface{}{"a": 1, "b": "abc"}
+			got, err := FormatFixedWidth(tt.spec, vals[tt.spec[0].Name], vals[tt.spec[1].Name])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixedWidthRoundTrip(t *testing.T) {
+	spec, err := ParseFortranFormat("(I5,2X,F8.2,E12.4,D12.4,A6)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"id", "f", "e", "d", "name"}
+	for i := range spec {
+		spec[i].Name = names[i]
+	}
+	tb := NewTable()
+	tb.AddFloats("id", []float64{1, 22, 333})
+	tb.AddFloats("f", []float64{1.25, -0.5, math.NaN()})
+	tb.AddFloats("e", []float64{12.345, -1.5e-7, 2.5e150})
+	tb.AddFloats("d", []float64{0.001, 1e10, -7})
+	tb.AddStrings("name", []string{"a", "bcdef", "toolongname"})
+
+	var b bytes.Buffer
+	if err := WriteFixedWidthTo(&b, "header", spec, tb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	if len(lines) != 4 || lines[1] != "    1      1.25  0.1235E+02  0.1000D-02     a" {
+		t.Fatalf("unexpected output:\n%s", b.String())
+	}
+	// writing what was read reproduces the input exactly
+	rt, err := ReadFixedWidthFrom(bytes.NewReader(b.Bytes()), spec, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b2 bytes.Buffer
+	if err := WriteFixedWidthTo(&b2, "header", spec, rt); err != nil {
+		t.Fatal(err)
+	}
+	if b2.String() != b.String() {
+		t.Fatalf("round trip differs:\n%s\n%s", b.String(), b2.String())
+	}
+	if e := rt.Floats("e"); e[2] != 2.5e150 || !math.IsNaN(rt.Floats("f")[2]) {
+		t.Fatalf("values read: %v %v", e, rt.Floats("f"))
+	}
+	if s := rt.Strings("name"); s[2] != "toolon" {
+		t.Fatalf("strings read: %q", s)
+	}
+}
@@ -0,0 +1,46 @@
+package mmio
+
+import (
+	"io"
+	"io/fs"
+)
+
+// The path-based readers and writers of mmio are wrappers around variants taking an
+// io.Reader (suffix From) or an io.Writer (suffix To), usable with embedded files,
+// archive members, network bodies or in-memory buffers. ReadFS bridges these to an fs.FS:
+//
+//	d, err := mmio.ReadFS(fsys, "obs.csv", func(r io.Reader) ([][]float64, error) {
+//		return mmio.ReadCSVFrom(r, 1)
+//	})
+
+// ReadFS opens name from fsys and hands it to read
+func ReadFS[T any](fsys fs.FS, name string, read func(r io.Reader) (T, error)) (T, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		var z T
+		return z, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// readPath opens fp and hands it to read; errors are wrapped with op and fp (see pathError)
+func readPath[T any](op, fp string, read func(r io.Reader) (T, error)) (T, error) {
+	var z T
+	f, err := openRead(fp)
+	if err != nil {
+		return z, pathError(op, fp, err)
+	}
+	defer f.Close()
+	v, err := read(f)
+	if err != nil {
+		return z, pathError(op, fp, err)
+	}
+	return v, nil
+}
+
+// nopOutput is an outputFile over a caller's io.Writer, which is neither closed nor discarded
+type nopOutput struct{ io.Writer }
+
+func (nopOutput) Close() error { return nil }
+func (nopOutput) Abort() error { return nil }
@@ -0,0 +1,85 @@
+package mmio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"obs.csv":   {Data: []byte("a,b\n1,2\n3,4\n")},
+		"lines.txt": {Data: []byte(" x \ny\n")},
+	}
+	d, err := ReadFS(fsys, "obs.csv", func(r io.Reader) ([][]float64, error) { return ReadCSVFrom(r, 1) })
+	if err != nil || len(d) != 2 || d[1][1] != 4 {
+		t.Fatalf("ReadCSVFrom: %v %v", d, err)
+	}
+	l, err := ReadFS(fsys, "lines.txt", ReadTextLinesFrom)
+	if err != nil || len(l) != 2 || l[0] != "x" {
+		t.Fatalf("ReadTextLinesFrom: %q %v", l, err)
+	}
+	if _, err := ReadFS(fsys, "missing", ReadTextLinesFrom); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestReadCSVParseErrorStopsReader(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("h1,h2\nx,1\n")
+	for i := 0; i < 100000; i++ {
+		sb.WriteString("1,2\n")
+	}
+	fp := filepath.Join(t.TempDir(), "bad.csv")
+	if err := os.WriteFile(fp, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCSV(fp, 1); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if _, err := ReadCsvStringInt(fp); err != nil { // "x,1" is valid here
+		t.Fatal(err)
+	}
+}
+
+func TestReadPathErrors(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "d.csv")
+	os.WriteFile(fp, []byte("date,v\nnot-a-date,1\n"), 0644)
+	_, err := ReadCsvDateFloat(fp)
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || pe.Op != "mmio.ReadCsvDateFloat" || pe.Path != fp {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := ReadCsvDateFloat(fp + ".missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestFromToRoundTrip(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCsvMapTo(&b, "k,v", map[int]float64{1: 2.5, 3: 4}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadCsvStringFloatFrom(&b)
+	if err != nil || len(m) != 2 || m["1"] != 2.5 || m["3"] != 4 {
+		t.Fatalf("%v %v", m, err)
+	}
+	b.Reset()
+	if err := WriteBinaryTo(&b, []float64{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := ReadBinaryFloatsFrom(&b)
+	if err != nil || len(f) != 3 || f[2] != 3 {
+		t.Fatalf("%v %v", f, err)
+	}
+	b.Reset()
+	if err := ArrayToPNGTo(&b, []float64{1, 2, 3, 4}, 2, 2); err != nil || !bytes.HasPrefix(b.Bytes(), []byte("\x89PNG")) {
+		t.Fatalf("ArrayToPNGTo: %v", err)
+	}
+}
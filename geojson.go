@@ -1,15 +1,31 @@
 package mmio
 
 import (
-	"io/ioutil"
+	"fmt"
+	"io"
 	"log"
 
 	"github.com/maseology/mmaths"
 	geojson "github.com/paulmach/go.geojson"
 )
 
+// LineSegmentsToGeojson writes line segments to outfp as a geojson feature collection; errors are logged, use LineSegmentsToGeojsonE to handle them
 func LineSegmentsToGeojson(lns map[int]mmaths.LineSegment, outfp string) {
-	DeleteFile(outfp)
+	if err := LineSegmentsToGeojsonE(lns, outfp); err != nil {
+		log.Println(err)
+	}
+}
+
+// LineSegmentsToGeojsonE writes line segments to outfp as a geojson feature collection
+func LineSegmentsToGeojsonE(lns map[int]mmaths.LineSegment, outfp string) error {
+	if err := writeOutput(outfp, false, func(w io.Writer) error { return LineSegmentsToGeojsonTo(w, lns) }); err != nil {
+		return fmt.Errorf("LineSegmentsToGeojson: %w", err)
+	}
+	return nil
+}
+
+// LineSegmentsToGeojsonTo writes line segments to w as a geojson feature collection
+func LineSegmentsToGeojsonTo(w io.Writer, lns map[int]mmaths.LineSegment) error {
 	fc := geojson.NewFeatureCollection()
 	for i, l := range lns {
 		ft := geojson.NewLineStringFeature([][]float64{{l.P0.X, l.P0.Y}, {l.P1.X, l.P1.Y}})
@@ -20,20 +36,38 @@ func LineSegmentsToGeojson(lns map[int]mmaths.LineSegment, outfp string) {
 
 	rawJSON, err := fc.MarshalJSON()
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	WriteString(outfp, string(rawJSON)+"\n")
+	return WriteStringTo(w, string(rawJSON)+"\n")
 }
 
+// ReadGeojsonLines reads the (multi)line string geometries of a geojson feature collection; it panics on error, use ReadGeojsonLinesE to handle them
 func ReadGeojsonLines(fp string) [][][]float64 {
-	flines, err := ioutil.ReadFile(fp)
+	o, err := ReadGeojsonLinesE(fp)
 	if err != nil {
 		panic(err)
 	}
-	glines, err := geojson.UnmarshalFeatureCollection(flines)
+	return o
+}
+
+// ReadGeojsonLinesE reads the (multi)line string geometries of a geojson feature collection
+func ReadGeojsonLinesE(fp string) ([][][]float64, error) {
+	return readPath("ReadGeojsonLines", fp, ReadGeojsonLinesFrom)
+}
+
+// ReadGeojsonLinesFrom reads the (multi)line string geometries of a geojson feature collection from r
+func ReadGeojsonLinesFrom(r io.Reader) ([][][]float64, error) {
+	b, err := io.ReadAll(r)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	return geojsonLines(b)
+}
+
+func geojsonLines(b []byte) ([][][]float64, error) {
+	glines, err := geojson.UnmarshalFeatureCollection(b)
+	if err != nil {
+		return nil, err
 	}
 
 	var o [][][]float64
@@ -45,8 +79,8 @@ func ReadGeojsonLines(fp string) [][][]float64 {
 		case "MultiLineString":
 			o = append(o, f.Geometry.MultiLineString...)
 		default:
-			log.Fatalf("Routing.LoadNetwork: unsupported type, given %v\n", f.Geometry.Type)
+			return nil, fmt.Errorf("unsupported geometry type %v", f.Geometry.Type)
 		}
 	}
-	return o
+	return o, nil
 }
@@ -2,35 +2,30 @@ package mmio
 
 import (
 	"encoding/gob"
-	"os"
+	"io"
 )
 
 // SaveGOB saves map[int]int
 func SaveGOB(fp string, d map[int]int) error {
-	f, err := os.Create(fp)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	enc := gob.NewEncoder(f)
-	err = enc.Encode(d)
-	if err != nil {
-		return err
-	}
-	return nil
+	return writeOutput(fp, false, func(f io.Writer) error { return SaveGOBTo(f, d) })
+}
+
+// SaveGOBTo encodes map[int]int to w
+func SaveGOBTo(w io.Writer, d map[int]int) error {
+	enc := gob.NewEncoder(w)
+	return enc.Encode(d)
 }
 
 // LoadGOB saves map[int]int
 func LoadGOB(fp string) (map[int]int, error) {
+	return readPath("LoadGOB", fp, LoadGOBFrom)
+}
+
+// LoadGOBFrom decodes map[int]int from r
+func LoadGOBFrom(r io.Reader) (map[int]int, error) {
 	var d map[int]int
-	f, err := os.Open(fp)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	enc := gob.NewDecoder(f)
-	err = enc.Decode(&d)
-	if err != nil {
+	enc := gob.NewDecoder(r)
+	if err := enc.Decode(&d); err != nil {
 		return nil, err
 	}
 	return d, nil
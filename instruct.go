@@ -1,6 +1,7 @@
 package mmio
 
 import (
+	"io"
 	"log"
 	"strings"
 )
@@ -16,6 +17,19 @@ func NewInstruct(filepath string) *Instruct {
 	if err != nil {
 		log.Fatal(err)
 	}
+	return parseInstruct(a)
+}
+
+// NewInstructFrom parses an instruction file read from r
+func NewInstructFrom(r io.Reader) (*Instruct, error) {
+	a, err := ReadTextLinesFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseInstruct(a), nil
+}
+
+func parseInstruct(a []string) *Instruct {
 	ins := Instruct{Param: make(map[string][]string), Group: make(map[string][][]string)}
 	grp := ""
 	for _, s := range a {
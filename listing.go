@@ -0,0 +1,273 @@
+package mmio
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ListSort is the order of the entries returned by ListFiles
+type ListSort int
+
+const (
+	SortNone    ListSort = iota // walk order (lexical per directory)
+	SortByName                  // relative path
+	SortBySize                  // file size, then relative path
+	SortByMtime                 // modification time, then relative path
+)
+
+// ListOptions controls ListFiles. Patterns are doublestar globs matched against the
+// slash-separated path relative to the root: "*" and "?" do not cross "/", "**" matches
+// any number of directories, and "{a,b}" lists alternatives, e.g. "**/*.{csv,txt}".
+type ListOptions struct {
+	Patterns      []string // files to list (all files if empty)
+	Exclude       []string // files and directories to skip; patterns without "/" match the base name at any depth
+	CaseSensitive bool     // matching is case-insensitive by default
+	MaxDepth      int      // 1: entries of root only, 2: and of its subdirectories, ...; 0: unlimited
+	Hidden        bool     // include dot-files and the contents of dot-directories
+	Dirs          bool     // also list directories (matched against Patterns as files are)
+	Sort          ListSort
+	Reverse       bool
+}
+
+// FileEntry is a listed file with its metadata; it satisfies fs.DirEntry
+type FileEntry struct {
+	Path    string // root-prefixed, slash-separated, as FileList
+	Rel     string // slash-separated path relative to the root
+	Size    int64
+	ModTime time.Time
+	info    fs.FileInfo
+}
+
+// Name returns the base name of the entry
+func (e FileEntry) Name() string { return e.info.Name() }
+
+// IsDir reports whether the entry is a directory
+func (e FileEntry) IsDir() bool { return e.info.IsDir() }
+
+// Type returns the type bits of the entry
+func (e FileEntry) Type() fs.FileMode { return e.info.Mode().Type() }
+
+// Info returns the fs.FileInfo of the entry
+func (e FileEntry) Info() (fs.FileInfo, error) { return e.info, nil }
+
+// ListFiles recursively lists the files under root that match opt
+func ListFiles(root string, opt ListOptions) ([]FileEntry, error) {
+	fold := func(s string) string {
+		if opt.CaseSensitive {
+			return s
+		}
+		return strings.ToLower(s)
+	}
+	pats, excl := compileGlobs(opt.Patterns, fold, false), compileGlobs(opt.Exclude, fold, true)
+
+	var a []FileEntry
+	err := filepath.WalkDir(root, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, fp)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		depth := strings.Count(rel, "/") + 1
+		skip := func() error {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !opt.Hidden && strings.HasPrefix(d.Name(), ".") {
+			return skip()
+		}
+		frel := fold(rel)
+		for _, g := range excl {
+			if g.match(frel) {
+				return skip()
+			}
+		}
+		if !d.IsDir() || opt.Dirs {
+			ok := len(pats) == 0
+			for _, g := range pats {
+				if ok = g.match(frel); ok {
+					break
+				}
+			}
+			if ok {
+				fi, err := d.Info()
+				if err != nil {
+					return err
+				}
+				a = append(a, FileEntry{filepath.ToSlash(fp), rel, fi.Size(), fi.ModTime(), fi})
+			}
+		}
+		if d.IsDir() && opt.MaxDepth > 0 && depth >= opt.MaxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, pathError("ListFiles", root, err)
+	}
+
+	var less func(i, j int) bool
+	switch opt.Sort {
+	case SortByName:
+		less = func(i, j int) bool { return a[i].Rel < a[j].Rel }
+	case SortBySize:
+		less = func(i, j int) bool {
+			if a[i].Size == a[j].Size {
+				return a[i].Rel < a[j].Rel
+			}
+			return a[i].Size < a[j].Size
+		}
+	case SortByMtime:
+		less = func(i, j int) bool {
+			if a[i].ModTime.Equal(a[j].ModTime) {
+				return a[i].Rel < a[j].Rel
+			}
+			return a[i].ModTime.Before(a[j].ModTime)
+		}
+	}
+	if less != nil {
+		sort.SliceStable(a, less)
+	}
+	if opt.Reverse {
+		for i, j := 0, len(a)-1; i < j; i, j = i+1, j-1 {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	return a, nil
+}
+
+// ListPaths returns the paths of the entries of ListFiles
+func ListPaths(root string, opt ListOptions) ([]string, error) {
+	es, err := ListFiles(root, opt)
+	if err != nil {
+		return nil, err
+	}
+	s := make([]string, len(es))
+	for i, e := range es {
+		s[i] = e.Path
+	}
+	return s, nil
+}
+
+// FileListExts lists the files of dir having any of the given extensions (e.g. ".csv", "txt"),
+// ignoring case, optionally recursing into subdirectories
+func FileListExts(dir string, recursive bool, exts ...string) ([]string, error) {
+	opt := ListOptions{Sort: SortByName, MaxDepth: 1}
+	if recursive {
+		opt.MaxDepth = 0
+	}
+	for _, x := range exts {
+		opt.Patterns = append(opt.Patterns, "**/*."+strings.TrimPrefix(x, "."))
+	}
+	return ListPaths(dir, opt)
+}
+
+// glob is a doublestar pattern with its braces expanded and split into path segments
+type glob struct {
+	alts     [][]string
+	baseOnly bool // pattern without "/": matched against the base name
+}
+
+// compileGlobs prepares patterns; with baseNames, patterns without "/" match base names at any depth
+func compileGlobs(patterns []string, fold func(string) string, baseNames bool) []glob {
+	gs := make([]glob, 0, len(patterns))
+	for _, p := range patterns {
+		p = fold(strings.Trim(filepath.ToSlash(p), "/"))
+		g := glob{baseOnly: baseNames && !strings.Contains(p, "/")}
+		for _, e := range expandBraces(p) {
+			g.alts = append(g.alts, strings.Split(e, "/"))
+		}
+		gs = append(gs, g)
+	}
+	return gs
+}
+
+// match tests a slash-separated relative path
+func (g glob) match(rel string) bool {
+	segs := strings.Split(rel, "/")
+	if g.baseOnly {
+		segs = segs[len(segs)-1:]
+	}
+	for _, a := range g.alts {
+		if matchSegments(a, segs) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for k := 0; k <= len(segs); k++ {
+				if matchSegments(pat[1:], segs[k:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// expandBraces expands "{a,b}" alternatives, including nested ones, into separate patterns
+func expandBraces(p string) []string {
+	lvl, o := 0, -1
+	for i, c := range p {
+		switch c {
+		case '{':
+			if lvl == 0 {
+				o = i
+			}
+			lvl++
+		case '}':
+			if lvl--; lvl == 0 && o >= 0 {
+				var a []string
+				pre, post := p[:o], p[i+1:]
+				for _, alt := range splitBraceAlts(p[o+1 : i]) {
+					a = append(a, expandBraces(pre+alt+post)...)
+				}
+				return a
+			}
+		}
+	}
+	return []string{p}
+}
+
+// splitBraceAlts splits on commas outside of nested braces
+func splitBraceAlts(s string) []string {
+	var a []string
+	lvl, b := 0, 0
+	for i, c := range s {
+		switch c {
+		case '{':
+			lvl++
+		case '}':
+			lvl--
+		case ',':
+			if lvl == 0 {
+				a = append(a, s[b:i])
+				b = i + 1
+			}
+		}
+	}
+	return append(a, s[b:])
+}
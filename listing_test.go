@@ -0,0 +1,100 @@
+package mmio
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.csv", "a.csv", true},
+		{"*.csv", "d/a.csv", false},
+		{"**/*.csv", "a.csv", true},
+		{"**/*.csv", "d/e/a.csv", true},
+		{"d/**", "d/e/a.csv", true},
+		{"d/**/a.csv", "d/a.csv", true},
+		{"d/*/a.csv", "d/a.csv", false},
+		{"?.csv", "ab.csv", false},
+		{"**/*.{csv,txt}", "d/b.txt", true},
+		{"**/*.{csv,txt}", "d/b.bin", false},
+		{"{a,b}/{c,d}.x", "b/c.x", true},
+		{"[ab].csv", "b.csv", true},
+	}
+	for _, tt := range tests {
+		g := compileGlobs([]string{tt.pattern}, func(s string) string { return s }, false)[0]
+		if got := g.match(tt.rel); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	root := t.TempDir()
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := []struct {
+		rel  string
+		size int
+	}{
+		{"b.csv", 30}, {"a.TXT", 10}, {"run/c.csv", 20}, {"run/out/d.csv", 5},
+		{"run/tmp/e.csv", 1}, {".hidden/f.csv", 1}, {"g.bin", 40},
+	}
+	for i, f := range files {
+		fp := filepath.Join(root, filepath.FromSlash(f.rel))
+		os.MkdirAll(filepath.Dir(fp), 0755)
+		if err := os.WriteFile(fp, []byte(strings.Repeat("x", f.size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mt := t0.Add(time.Duration(len(files)-i) * time.Hour)
+		os.Chtimes(fp, mt, mt)
+	}
+
+	tests := []struct {
+		name string
+		opt  ListOptions
+		want []string
+	}{
+		{"all by name", ListOptions{Sort: SortByName}, []string{"a.TXT", "b.csv", "g.bin", "run/c.csv", "run/out/d.csv", "run/tmp/e.csv"}},
+		{"pattern", ListOptions{Patterns: []string{"**/*.csv"}, Sort: SortByName}, []string{"b.csv", "run/c.csv", "run/out/d.csv", "run/tmp/e.csv"}},
+		{"case-insensitive", ListOptions{Patterns: []string{"*.txt"}}, []string{"a.TXT"}},
+		{"case-sensitive", ListOptions{Patterns: []string{"*.txt"}, CaseSensitive: true}, nil},
+		{"exclude base name", ListOptions{Patterns: []string{"**/*.csv"}, Exclude: []string{"tmp"}, Sort: SortByName}, []string{"b.csv", "run/c.csv", "run/out/d.csv"}},
+		{"max depth", ListOptions{Patterns: []string{"**/*.csv"}, MaxDepth: 2, Sort: SortByName}, []string{"b.csv", "run/c.csv"}},
+		{"hidden", ListOptions{Patterns: []string{"**/f.csv"}, Hidden: true}, []string{".hidden/f.csv"}},
+		{"dirs", ListOptions{Patterns: []string{"run/*"}, Dirs: true, Sort: SortByName}, []string{"run/c.csv", "run/out", "run/tmp"}},
+		{"by size, reversed", ListOptions{Sort: SortBySize, Reverse: true, MaxDepth: 1}, []string{"g.bin", "b.csv", "a.TXT"}},
+		{"by time", ListOptions{Sort: SortByMtime, Patterns: []string{"**/*.csv"}}, []string{"run/tmp/e.csv", "run/out/d.csv", "run/c.csv", "b.csv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es, err := ListFiles(root, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range es {
+				got = append(got, e.Rel)
+				if e.Path != filepath.ToSlash(filepath.Join(root, e.Rel)) {
+					t.Errorf("%s: path %s", e.Rel, e.Path)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listed %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	ps, err := FileListExts(root, false, ".csv", "txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 2 || !strings.HasSuffix(ps[0], "/a.TXT") || !strings.HasSuffix(ps[1], "/b.csv") {
+		t.Errorf("FileListExts = %q", ps)
+	}
+}
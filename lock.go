@@ -0,0 +1,185 @@
+package mmio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockMode selects a shared (readers) or exclusive (writers) advisory lock
+type LockMode int
+
+const (
+	LockShared LockMode = iota
+	LockExclusive
+)
+
+// ErrLockTimeout is returned when a lock could not be acquired within its timeout
+var ErrLockTimeout = errors.New("lock timeout")
+
+// LockAppends, when set, makes the appending writers (WriteRMAP with append, NewCSVwriterAppend)
+// hold an exclusive lock on their file while writing, and the GetInstance log for each line, and LockReads makes the path-based readers
+// (ReadCSV, ReadTextLines, ReadFixedWidth, ReadCsvTable, ...) hold a shared lock while reading,
+// both waiting up to LockTimeout. On unix, locks are advisory: only processes that also lock are
+// kept out. On Windows they are mandatory for the whole file. Where file locking is unsupported,
+// both settings are ignored and files are opened unlocked. These settings are read without
+// synchronization and apply process-wide: set them once at startup, before any file is opened.
+// Individual files can be locked with OpenLocked or LockFile instead.
+var (
+	LockAppends = false
+	LockReads   = false
+	LockTimeout = 30 * time.Second
+)
+
+// LockedFile is an open file holding a lock (flock on unix, LockFileEx on Windows), released by Close.
+// Elsewhere, locking returns an error satisfying errors.Is(err, errors.ErrUnsupported).
+type LockedFile struct {
+	*os.File
+}
+
+// OpenLocked opens fp as os.OpenFile does and locks it. A negative timeout waits
+// indefinitely, zero tries once; an expired timeout returns an error satisfying
+// errors.Is(err, ErrLockTimeout).
+func OpenLocked(fp string, flag int, perm os.FileMode, mode LockMode, timeout time.Duration) (*LockedFile, error) {
+	f, err := os.OpenFile(fp, flag, perm)
+	if err != nil {
+		return nil, pathError("OpenLocked", fp, err)
+	}
+	if err := lockWait(f, mode, timeout); err != nil {
+		f.Close()
+		return nil, pathError("OpenLocked", fp, err)
+	}
+	return &LockedFile{f}, nil
+}
+
+// LockFile locks fp for reading (LockShared) or writing (LockExclusive, creating fp if needed)
+func LockFile(fp string, mode LockMode, timeout time.Duration) (*LockedFile, error) {
+	if mode == LockExclusive {
+		return OpenLocked(fp, os.O_RDWR|os.O_CREATE, 0644, mode, timeout)
+	}
+	return OpenLocked(fp, os.O_RDONLY, 0, mode, timeout)
+}
+
+// Close releases the lock and closes the file
+func (l *LockedFile) Close() error {
+	uerr := unlockFile(l.File)
+	if err := l.File.Close(); err != nil {
+		return err
+	}
+	return uerr
+}
+
+func lockWait(f *os.File, mode LockMode, timeout time.Duration) error {
+	deadline, wait := time.Now().Add(timeout), 5*time.Millisecond
+	for {
+		ok, err := tryLockFile(f, mode)
+		if err != nil || ok {
+			return err
+		}
+		if timeout >= 0 && !time.Now().Before(deadline) {
+			return ErrLockTimeout
+		}
+		time.Sleep(wait)
+		if wait < 100*time.Millisecond {
+			wait *= 2
+		}
+	}
+}
+
+// openAppend opens fp for appending writers, locked exclusively if LockAppends is set
+func openAppend(fp string, flag int) (*os.File, error) {
+	if !LockAppends {
+		return os.OpenFile(fp, flag, 0644)
+	}
+	return openLockedFallback(fp, flag, 0644, LockExclusive)
+}
+
+// lockedAppender appends to a file kept open between writes (the GetInstance log). When
+// LockAppends is set, each write holds an exclusive lock, so that other processes sharing the
+// file are only kept out while a line is written, not for the lifetime of the writer.
+type lockedAppender struct {
+	*os.File
+}
+
+func (a lockedAppender) Write(b []byte) (int, error) {
+	if LockAppends {
+		if err := lockWait(a.File, LockExclusive, LockTimeout); err == nil {
+			defer unlockFile(a.File)
+		} else if !errors.Is(err, errors.ErrUnsupported) {
+			return 0, pathError("Write", a.File.Name(), err)
+		}
+	}
+	return a.File.Write(b)
+}
+
+// openRead opens fp for path-based readers, with a shared lock if LockReads is set
+func openRead(fp string) (*os.File, error) {
+	if !LockReads {
+		return os.Open(fp)
+	}
+	return openLockedFallback(fp, os.O_RDONLY, 0, LockShared)
+}
+
+// readFile is os.ReadFile, with a shared lock if LockReads is set
+func readFile(fp string) ([]byte, error) {
+	if !LockReads {
+		return os.ReadFile(fp)
+	}
+	f, err := openRead(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// openLockedFallback is OpenLocked waiting up to LockTimeout, opening fp unlocked where locking is unsupported
+func openLockedFallback(fp string, flag int, perm os.FileMode, mode LockMode) (*os.File, error) {
+	l, err := OpenLocked(fp, flag, perm, mode, LockTimeout)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return os.OpenFile(fp, flag, perm)
+	} else if err != nil {
+		return nil, err
+	}
+	return l.File, nil // closing the file releases the lock
+}
+
+// DirLockName is the lock file created by LockDir
+const DirLockName = ".mmio.lock"
+
+// DirLock marks a directory as in use by one process (a run, a workspace), released by Close
+type DirLock struct {
+	*LockedFile
+	Dir string
+}
+
+// LockDir takes the exclusive lock of dir, waiting up to timeout (see OpenLocked). The lock file
+// DirLockName records the holder's pid, host and start time. It is left in place on Close, since
+// removing it would let a waiting process lock a file that no longer exists.
+func LockDir(dir string, timeout time.Duration) (*DirLock, error) {
+	fp := filepath.Join(dir, DirLockName)
+	l, err := OpenLocked(fp, os.O_RDWR|os.O_CREATE, 0644, LockExclusive, timeout)
+	if err != nil {
+		if errors.Is(err, ErrLockTimeout) {
+			if b, rerr := os.ReadFile(fp); rerr == nil && len(b) > 0 {
+				return nil, fmt.Errorf("LockDir %s: %w (held by %s)", dir, err, trimNewline(string(b)))
+			}
+		}
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	if err := l.Truncate(0); err == nil {
+		fmt.Fprintf(l, "pid %d on %s since %s\n", os.Getpid(), host, time.Now().Format(time.RFC3339))
+	}
+	return &DirLock{l, dir}, nil
+}
+
+func trimNewline(s string) string {
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == '\r') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows)
+
+package mmio
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLockFile(f *os.File, mode LockMode) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
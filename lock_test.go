@@ -0,0 +1,161 @@
+package mmio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(fp, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		held, want  LockMode
+		wantTimeout bool
+	}{
+		{"shared, shared", LockShared, LockShared, false},
+		{"shared, exclusive", LockShared, LockExclusive, true},
+		{"exclusive, shared", LockExclusive, LockShared, true},
+		{"exclusive, exclusive", LockExclusive, LockExclusive, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := LockFile(fp, tt.held, 0)
+			if errors.Is(err, errors.ErrUnsupported) {
+				t.Skip(err)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			l2, err := LockFile(fp, tt.want, 20*time.Millisecond)
+			if got := errors.Is(err, ErrLockTimeout); got != tt.wantTimeout {
+				t.Fatalf("timeout = %v (%v), want %v", got, err, tt.wantTimeout)
+			}
+			if err == nil {
+				l2.Close()
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+			l2, err = LockFile(fp, tt.want, 0) // released by Close
+			if err != nil {
+				t.Fatal(err)
+			}
+			l2.Close()
+		})
+	}
+}
+
+func TestLockReads(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(fp, []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(r bool, d time.Duration) { LockReads, LockTimeout = r, d }(LockReads, LockTimeout)
+	LockTimeout = 20 * time.Millisecond
+
+	l, err := LockFile(fp, LockExclusive, 0)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for _, lockReads := range []bool{false, true} {
+		LockReads = lockReads
+		_, err := readPath("ReadTextLines", fp, ReadTextLinesFrom)
+		if got := errors.Is(err, ErrLockTimeout); got != lockReads {
+			t.Errorf("LockReads %v: read error %v", lockReads, err)
+		}
+	}
+}
+
+func TestLockDir(t *testing.T) {
+	dir := t.TempDir()
+	l, err := LockDir(dir, 0)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockDir(dir, 0); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("second LockDir: %v, want %v", err, ErrLockTimeout)
+	}
+	l.Close()
+	if l, err = LockDir(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+}
+
+func TestLoggerConcurrentAppends(t *testing.T) {
+	withBackups(t, BackupPolicy{}, false)
+	defer func(a bool, d time.Duration) { LockAppends, LockTimeout = a, d }(LockAppends, LockTimeout)
+	LockAppends, LockTimeout = true, 5*time.Second
+
+	fp := filepath.Join(t.TempDir(), "mm.log")
+	const nlog, nline = 4, 200
+	logs := make([]*logger, nlog) // one per "process", each with its own file handle
+	for i := range logs {
+		logs[i] = createLogger(fp)
+	}
+	var wg sync.WaitGroup
+	for i, l := range logs {
+		wg.Add(1)
+		go func(i int, l *logger) {
+			defer wg.Done()
+			for j := 0; j < nline; j++ {
+				l.Printf("logger %d line %d %s", i, j, strings.Repeat("x", 100))
+			}
+		}(i, l)
+	}
+	wg.Wait()
+	for _, l := range logs {
+		l.Writer().(lockedAppender).Close()
+	}
+
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != nlog*nline {
+		t.Fatalf("%d lines, want %d", len(lines), nlog*nline)
+	}
+	for _, ln := range lines {
+		if !strings.HasSuffix(ln, strings.Repeat("x", 100)) || strings.Count(ln, "logger") != 1 {
+			t.Fatalf("interleaved line %q", ln)
+		}
+	}
+}
+
+func TestLockedAppenderWaits(t *testing.T) {
+	defer func(a bool, d time.Duration) { LockAppends, LockTimeout = a, d }(LockAppends, LockTimeout)
+	LockAppends, LockTimeout = true, 20*time.Millisecond
+
+	fp := filepath.Join(t.TempDir(), "mm.log")
+	l, err := LockFile(fp, LockExclusive, 0)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(fp, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := (lockedAppender{f}).Write([]byte("a\n")); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("write while locked: %v, want %v", err, ErrLockTimeout)
+	}
+	l.Close()
+	if _, err := (lockedAppender{f}).Write([]byte("a\n")); err != nil {
+		t.Errorf("write after unlock: %v", err)
+	}
+}
@@ -16,7 +16,7 @@ type logger struct {
 var mmlog *logger
 var once sync.Once
 
-// GetInstance start log file outputting to ./mm.log
+// GetInstance start log file outputting to ./mm.log; a previous log is kept according to Backups
 func GetInstance(fnam string) *logger {
 	once.Do(func() {
 		mmlog = createLogger(fnam)
@@ -24,12 +24,21 @@ func GetInstance(fnam string) *logger {
 	return mmlog
 }
 
+// createLogger opens the log in append mode (after truncating it, unless the backup failed) so
+// that processes sharing the file do not overwrite each other's lines. It is not opened with
+// openAppend, which would hold the lock for as long as the log is open; with LockAppends set,
+// each line is written under the lock instead (see lockedAppender).
 func createLogger(fname string) *logger {
-	file, _ := os.OpenFile(fname, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0777)
+	flag := os.O_RDWR | os.O_CREATE | os.O_APPEND | os.O_TRUNC
+	if _, err := Backups.Backup(fname); err != nil {
+		log.Printf("GetInstance: %v; appending to the existing log", err)
+		flag = os.O_RDWR | os.O_CREATE | os.O_APPEND
+	}
+	file, _ := os.OpenFile(fname, flag, 0777)
 
 	return &logger{
 		filename: fname,
-		Logger:   log.New(file, "", log.Lshortfile),
+		Logger:   log.New(lockedAppender{file}, "", log.Lshortfile),
 	}
 }
 
@@ -0,0 +1,227 @@
+package mmio
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ManifestEntry is the size and SHA-256 digest of a file, by slash-separated path relative to the manifest root
+type ManifestEntry struct {
+	Path   string
+	Size   int64
+	SHA256 string
+}
+
+// Manifest lists the files of a directory with their content hashes, sorted by path
+type Manifest []ManifestEntry
+
+// ManifestDiff lists the paths that differ between two manifests, or a directory and a manifest
+type ManifestDiff struct {
+	Missing []string // in the reference manifest only
+	Extra   []string // in the compared manifest/directory only
+	Changed []string // different size or hash
+}
+
+// Equal returns true when nothing is missing, extra or changed
+func (d *ManifestDiff) Equal() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// String lists the differences, one path per line
+func (d *ManifestDiff) String() string {
+	var sb strings.Builder
+	for _, p := range d.Missing {
+		sb.WriteString("missing: " + p + "\n")
+	}
+	for _, p := range d.Extra {
+		sb.WriteString("extra:   " + p + "\n")
+	}
+	for _, p := range d.Changed {
+		sb.WriteString("changed: " + p + "\n")
+	}
+	return sb.String()
+}
+
+// BuildManifest hashes every file under dir (see FileList) using nworkers goroutines
+// (nworkers < 1 uses GOMAXPROCS). Paths in exclude, relative to dir, are skipped; this
+// is typically the manifest file itself.
+func BuildManifest(dir string, nworkers int, exclude ...string) (Manifest, error) {
+	fps, err := FileList(dir)
+	if err != nil {
+		return nil, fmt.Errorf("BuildManifest: %v", err)
+	}
+	skip := make(map[string]bool, len(exclude))
+	for _, x := range exclude {
+		skip[filepath.ToSlash(filepath.Clean(x))] = true
+	}
+	m := make(Manifest, 0, len(fps))
+	for _, fp := range fps {
+		rel, err := filepath.Rel(dir, fp)
+		if err != nil {
+			return nil, fmt.Errorf("BuildManifest: %v", err)
+		}
+		if rel = filepath.ToSlash(rel); !skip[rel] {
+			m = append(m, ManifestEntry{Path: rel})
+		}
+	}
+	sort.Slice(m, func(i, j int) bool { return m[i].Path < m[j].Path })
+
+	if nworkers < 1 {
+		nworkers = runtime.GOMAXPROCS(0)
+	}
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	errs := make([]error, len(m))
+	for w := 0; w < nworkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				m[i].Size, m[i].SHA256, errs[i] = HashFile(filepath.Join(dir, filepath.FromSlash(m[i].Path)))
+			}
+		}()
+	}
+	for i := range m {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("BuildManifest: %v", err)
+		}
+	}
+	return m, nil
+}
+
+// HashFile returns the size and hex-encoded SHA-256 digest of a file
+func HashFile(fp string) (int64, string, error) {
+	f, err := openRead(fp)
+	if err != nil {
+		return 0, "", pathError("HashFile", fp, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", pathError("HashFile", fp, err)
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Write saves the manifest as a "path,size,sha256" csv, atomically
+func (m Manifest) Write(fp string) (err error) {
+	w, err := NewCSVwriterAtomic(fp)
+	if err != nil {
+		return fmt.Errorf("Manifest.Write: %v", err)
+	}
+	defer w.finish(&err)
+	return m.write(w)
+}
+
+// WriteCSVTo writes the manifest as csv to w, see Write
+func (m Manifest) WriteCSVTo(w io.Writer) error {
+	cw := NewCSVwriterTo(w)
+	if err := m.write(cw); err != nil {
+		return err
+	}
+	return cw.CloseE()
+}
+
+func (m Manifest) write(w *CSVwriter) error {
+	if err := w.WriteHead("path,size,sha256"); err != nil {
+		return err
+	}
+	for _, e := range m {
+		if err := w.WriteLine(e.Path, e.Size, e.SHA256); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadManifest reads a manifest written by Manifest.Write
+func ReadManifest(fp string) (Manifest, error) {
+	return readPath("ReadManifest", fp, ReadManifestFrom)
+}
+
+// ReadManifestFrom reads a manifest from r, see ReadManifest
+func ReadManifestFrom(rd io.Reader) (Manifest, error) {
+	recs, err := csv.NewReader(rd).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 || len(recs[0]) != 3 || strings.TrimPrefix(recs[0][0], "\uFEFF") != "path" {
+		return nil, fmt.Errorf("not a manifest (expecting header path,size,sha256)")
+	}
+	m := make(Manifest, 0, len(recs)-1)
+	for i, r := range recs[1:] {
+		sz, err := strconv.ParseInt(r[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+		m = append(m, ManifestEntry{r[0], sz, r[2]})
+	}
+	sort.Slice(m, func(i, j int) bool { return m[i].Path < m[j].Path })
+	return m, nil
+}
+
+// Digest returns a single SHA-256 over all entries: equal digests mean identical file sets
+// and contents, e.g. to skip a model run whose inputs have not changed since the last one
+func (m Manifest) Digest() string {
+	h := sha256.New()
+	for _, e := range m {
+		fmt.Fprintf(h, "%s\x00%d\x00%s\n", e.Path, e.Size, e.SHA256)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DiffManifests compares b against the reference a
+func DiffManifests(a, b Manifest) *ManifestDiff {
+	d := &ManifestDiff{}
+	mb := make(map[string]ManifestEntry, len(b))
+	for _, e := range b {
+		mb[e.Path] = e
+	}
+	for _, ea := range a {
+		eb, ok := mb[ea.Path]
+		if !ok {
+			d.Missing = append(d.Missing, ea.Path)
+		} else if ea.Size != eb.Size || !strings.EqualFold(ea.SHA256, eb.SHA256) {
+			d.Changed = append(d.Changed, ea.Path)
+		}
+		delete(mb, ea.Path)
+	}
+	for p := range mb {
+		d.Extra = append(d.Extra, p)
+	}
+	sort.Strings(d.Extra)
+	return d
+}
+
+// VerifyManifest hashes dir and compares it against the manifest file manifestfp;
+// the manifest file itself is ignored when it lies within dir
+func VerifyManifest(dir, manifestfp string, nworkers int) (*ManifestDiff, error) {
+	ref, err := ReadManifest(manifestfp)
+	if err != nil {
+		return nil, err
+	}
+	var excl []string
+	if rel, err := filepath.Rel(dir, manifestfp); err == nil && !strings.HasPrefix(rel, "..") {
+		excl = append(excl, rel)
+	}
+	m, err := BuildManifest(dir, nworkers, excl...)
+	if err != nil {
+		return nil, err
+	}
+	return DiffManifests(ref, m), nil
+}
@@ -0,0 +1,80 @@
+package mmio
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(dir string)
+		want   ManifestDiff
+	}{
+		{"unchanged", func(dir string) {}, ManifestDiff{}},
+		{"changed content", func(dir string) { os.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0644) }, ManifestDiff{Changed: []string{"a.txt"}}},
+		{"changed size", func(dir string) { os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("bb"), 0644) }, ManifestDiff{Changed: []string{"sub/b.txt"}}},
+		{"missing", func(dir string) { os.Remove(filepath.Join(dir, "a.txt")) }, ManifestDiff{Missing: []string{"a.txt"}}},
+		{"extra", func(dir string) { os.WriteFile(filepath.Join(dir, "c.txt"), nil, 0644) }, ManifestDiff{Extra: []string{"c.txt"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+			m, err := BuildManifest(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m) != 2 || m[0].Path != "a.txt" || m[1].Path != "sub/b.txt" || m[0].Size != 1 {
+				t.Fatalf("manifest %+v", m)
+			}
+			mfp := filepath.Join(dir, "manifest.csv")
+			if err := m.Write(mfp); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.change(dir)
+			d, err := VerifyManifest(dir, mfp, 2) // the manifest file itself is ignored
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*d, tt.want) || d.Equal() != reflect.DeepEqual(tt.want, ManifestDiff{}) {
+				t.Errorf("diff %+v, want %+v", *d, tt.want)
+			}
+			m2, err := BuildManifest(dir, 1, "manifest.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (m.Digest() == m2.Digest()) != d.Equal() {
+				t.Errorf("digests equal %v, manifests equal %v", m.Digest() == m2.Digest(), d.Equal())
+			}
+		})
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	m := Manifest{
+		{"a.txt", 1, "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"},
+		{"dir with space/b,c.txt", 0, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+	var buf bytes.Buffer
+	if err := m.WriteCSVTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadManifestFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("read back %+v, want %+v", got, m)
+	}
+
+	fp := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(fp, []byte("a"), 0644)
+	if n, h, err := HashFile(fp); err != nil || n != 1 || h != m[0].SHA256 {
+		t.Errorf("HashFile = %d, %s, %v", n, h, err)
+	}
+}
@@ -4,12 +4,21 @@ import (
 	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"math"
 	"os"
 )
 
 // ArrayToPNG prints a 2D array (as row-major 1D array) to a png
 func ArrayToPNG(fp string, v []float64, nr, nc int) {
+	if err := writeOutput(fp, false, func(w io.Writer) error { return ArrayToPNGTo(w, v, nr, nc) }); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// ArrayToPNGTo encodes a 2D array (as row-major 1D array) to w as a png
+func ArrayToPNGTo(w io.Writer, v []float64, nr, nc int) error {
 	img := image.NewRGBA(image.Rect(0, 0, nc, nr))
 	min, max := math.MaxFloat64, -math.MaxFloat64
 	for _, vv := range v {
@@ -26,16 +35,7 @@ func ArrayToPNG(fp string, v []float64, nr, nc int) {
 		}
 	}
 
-	f, err := os.Create(fp)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	err = png.Encode(f, img)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	return png.Encode(w, img)
 
 	// fmt.Println(img)
 	// matrix := [5][5]bool{}
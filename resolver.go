@@ -0,0 +1,118 @@
+package mmio
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Resolver locates input files referenced by instruction files. Paths are expanded
+// ($VAR, ${VAR} and a leading ~) then, unless absolute, searched for in each of Dirs in order.
+type Resolver struct {
+	Dirs   []string                // search directories, themselves expanded; "" or "." is the working directory
+	Getenv func(key string) string // defaults to os.Getenv
+}
+
+// NewResolver returns a Resolver searching dirs in order
+func NewResolver(dirs ...string) *Resolver {
+	return &Resolver{Dirs: dirs}
+}
+
+// NewResolverEnv returns a Resolver searching the directories listed in the environment
+// variable key (separated as PATH is, see filepath.SplitList), followed by dirs
+func NewResolverEnv(key string, dirs ...string) *Resolver {
+	return &Resolver{Dirs: append(filepath.SplitList(os.Getenv(key)), dirs...)}
+}
+
+// RelativeTo returns a copy of r that first searches the directory containing rootfp,
+// typically the instruction file naming the inputs
+func (r *Resolver) RelativeTo(rootfp string) *Resolver {
+	c := *r
+	c.Dirs = append([]string{GetFileDir(rootfp)}, r.Dirs...)
+	return &c
+}
+
+// ResolveError lists the locations tried by Resolve. It is returned wrapped in an *fs.PathError
+// and matches fs.ErrNotExist.
+type ResolveError struct {
+	Tried []string // every location checked, in order, and the entries that could not be expanded
+}
+
+func (e *ResolveError) Error() string {
+	return "file not found, tried:\n  " + strings.Join(e.Tried, "\n  ")
+}
+
+func (e *ResolveError) Unwrap() error { return fs.ErrNotExist }
+
+// Resolve returns the first existing location of fp. Search directories that cannot be expanded
+// (e.g., an undefined $DATA) are skipped; when fp itself cannot be expanded, it is searched for
+// as given, since file names may contain "$". Both cases are noted in the error's Tried list.
+func (r *Resolver) Resolve(fp string) (string, error) {
+	var tried []string
+	name, err := r.Expand(fp)
+	if err != nil {
+		tried = append(tried, fmt.Sprintf("%s: %v, searched for as given", fp, err))
+		name = fp
+	}
+	try := func(p string) bool {
+		tried = append(tried, p)
+		_, ok := FileExists(p)
+		return ok
+	}
+	if filepath.IsAbs(name) {
+		if try(name) {
+			return name, nil
+		}
+		return "", pathError("Resolve", fp, &ResolveError{tried})
+	}
+	for _, d := range r.Dirs {
+		xd, err := r.Expand(d)
+		if err != nil {
+			tried = append(tried, fmt.Sprintf("%s: %v, skipped", d, err))
+			continue
+		}
+		if p := filepath.Join(xd, name); try(p) {
+			return p, nil
+		}
+	}
+	return "", pathError("Resolve", fp, &ResolveError{tried})
+}
+
+// MustResolve is Resolve that panics when fp cannot be found
+func (r *Resolver) MustResolve(fp string) string {
+	p, err := r.Resolve(fp)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Expand substitutes environment variables ($VAR, ${VAR}) and a leading "~" (the user's
+// home directory) in fp. Undefined variables are an error rather than silently empty.
+func (r *Resolver) Expand(fp string) (string, error) {
+	getenv := r.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	var undef []string
+	s := os.Expand(fp, func(k string) string {
+		v := getenv(k)
+		if len(v) == 0 {
+			undef = append(undef, "$"+k)
+		}
+		return v
+	})
+	if len(undef) > 0 {
+		return "", fmt.Errorf("undefined %s", strings.Join(undef, ", "))
+	}
+	if s == "~" || strings.HasPrefix(s, "~/") || strings.HasPrefix(s, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		s = home + s[1:]
+	}
+	return filepath.Clean(s), nil
+}
@@ -0,0 +1,69 @@
+package mmio
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	d := t.TempDir()
+	lib, proj := filepath.Join(d, "lib"), filepath.Join(d, "proj")
+	os.MkdirAll(lib, 0755)
+	os.MkdirAll(proj, 0755)
+	os.WriteFile(filepath.Join(lib, "dem.bil"), nil, 0644)
+	os.WriteFile(filepath.Join(proj, "local.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(proj, "a$b.txt"), nil, 0644)
+	env := map[string]string{"DATA": lib, "PROJ": proj}
+
+	tests := []struct {
+		name string
+		dirs []string
+		fp   string
+		want string // "" for not found
+	}{
+		{"search order", []string{"$PROJ", "$DATA"}, "dem.bil", filepath.Join(lib, "dem.bil")},
+		{"variable in path", []string{"."}, "$DATA/dem.bil", filepath.Join(lib, "dem.bil")},
+		{"braced variable", nil, "${DATA}/dem.bil", filepath.Join(lib, "dem.bil")},
+		{"undefined search dir skipped", []string{"$NOPE", "$PROJ"}, "local.txt", filepath.Join(proj, "local.txt")},
+		{"literal dollar", []string{"$PROJ"}, "a$b.txt", filepath.Join(proj, "a$b.txt")},
+		{"not found", []string{"$PROJ", "$NOPE"}, "missing.txt", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(tt.dirs...)
+			r.Getenv = func(k string) string { return env[k] }
+			got, err := r.Resolve(tt.fp)
+			if tt.want == "" {
+				var pe *fs.PathError
+				if !errors.Is(err, fs.ErrNotExist) || !errors.As(err, &pe) {
+					t.Fatalf("got %q %v, want a not-exist path error", got, err)
+				}
+				if !strings.Contains(err.Error(), "$NOPE") || !strings.Contains(err.Error(), filepath.Join(proj, "missing.txt")) {
+					t.Fatalf("error does not list the locations tried: %v", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("got %q %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelativeFileCheckE(t *testing.T) {
+	d := t.TempDir()
+	os.WriteFile(filepath.Join(d, "in$put.txt"), nil, 0644)
+	got, err := RelativeFileCheckE(filepath.Join(d, "ins.txt"), "in$put.txt")
+	if err != nil || got != d+"/in$put.txt" {
+		t.Fatalf("got %q %v", got, err)
+	}
+	_, err = RelativeFileCheckE(filepath.Join(d, "ins.txt"), "missing.txt")
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,547 @@
+package mmio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table is a lightweight in-memory columnar table. Columns are either numeric
+// (float64, missing values as NaN) or text.
+type Table struct {
+	Head []string
+	cols []tcol
+	nrow int
+}
+
+type tcol struct {
+	f []float64 // numeric column when s == nil
+	s []string
+}
+
+func (c tcol) numeric() bool { return c.s == nil }
+
+func (c tcol) key(i int) string {
+	if c.numeric() {
+		return strconv.FormatFloat(c.f[i], 'g', -1, 64)
+	}
+	return c.s[i]
+}
+
+func (c tcol) pick(ii []int) tcol {
+	var o tcol
+	if c.numeric() {
+		o.f = make([]float64, len(ii))
+		for k, i := range ii {
+			if i < 0 {
+				o.f[k] = math.NaN()
+			} else {
+				o.f[k] = c.f[i]
+			}
+		}
+	} else {
+		o.s = make([]string, len(ii))
+		for k, i := range ii {
+			if i >= 0 {
+				o.s[k] = c.s[i]
+			}
+		}
+	}
+	return o
+}
+
+// NewTable returns an empty table to which columns are added with AddFloats and AddStrings
+func NewTable() *Table {
+	return &Table{}
+}
+
+// AddFloats appends a numeric column
+func (t *Table) AddFloats(name string, v []float64) error {
+	if err := t.checkAdd(name, len(v)); err != nil {
+		return err
+	}
+	t.Head = append(t.Head, name)
+	t.cols = append(t.cols, tcol{f: v})
+	return nil
+}
+
+// AddStrings appends a text column
+func (t *Table) AddStrings(name string, v []string) error {
+	if err := t.checkAdd(name, len(v)); err != nil {
+		return err
+	}
+	t.Head = append(t.Head, name)
+	t.cols = append(t.cols, tcol{s: v})
+	return nil
+}
+
+func (t *Table) checkAdd(name string, n int) error {
+	if t.Index(name) >= 0 {
+		return fmt.Errorf("Table: column %s already exists", name)
+	}
+	if len(t.cols) > 0 && n != t.nrow {
+		return fmt.Errorf("Table: column %s has %d rows, table has %d", name, n, t.nrow)
+	}
+	t.nrow = n
+	return nil
+}
+
+// TableFromRecords builds a table from a header and string records (e.g., from LoadCsvArray).
+// A column is numeric when every non-empty cell parses as a float ("NA" is read as NaN).
+func TableFromRecords(head []string, recs [][]string) (*Table, error) {
+	t := &Table{Head: make([]string, len(head)), cols: make([]tcol, len(head)), nrow: len(recs)}
+	for j, h := range head {
+		t.Head[j] = strings.TrimSpace(h)
+	}
+	for i, rec := range recs {
+		if len(rec) != len(head) {
+			return nil, fmt.Errorf("TableFromRecords: record %d has %d fields, expected %d", i, len(rec), len(head))
+		}
+	}
+	for j := range head {
+		f, isnum := make([]float64, len(recs)), true
+		for i, rec := range recs {
+			c := strings.TrimSpace(rec[j])
+			if len(c) == 0 || c == "NA" {
+				f[i] = math.NaN()
+				continue
+			}
+			v, err := strconv.ParseFloat(c, 64)
+			if err != nil {
+				isnum = false
+				break
+			}
+			f[i] = v
+		}
+		if isnum {
+			t.cols[j] = tcol{f: f}
+		} else {
+			s := make([]string, len(recs))
+			for i, rec := range recs {
+				s[i] = rec[j]
+			}
+			t.cols[j] = tcol{s: s}
+		}
+	}
+	return t, nil
+}
+
+// ReadCsvTable reads a csv file with a single header line into a Table
+func ReadCsvTable(fp string) (*Table, error) {
+	f, err := openRead(fp)
+	if err != nil {
+		return nil, fmt.Errorf("ReadCsvTable failed: %v", err)
+	}
+	defer f.Close()
+	return ReadCsvTableFrom(f)
+}
+
+// ReadCsvTableFrom reads a csv with a single header line from rd into a Table
+func ReadCsvTableFrom(rd io.Reader) (*Table, error) {
+	r := csv.NewReader(rd)
+	head, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ReadCsvTable failed to read header: %v", err)
+	}
+	recs, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ReadCsvTable failed: %v", err)
+	}
+	return TableFromRecords(head, recs)
+}
+
+// WriteCSV writes the table to a csv file through CSVwriter
+func (t *Table) WriteCSV(fp string) error {
+	return writeOutput(fp, false, t.WriteCSVTo)
+}
+
+// WriteCSVTo writes the table as csv to w
+func (t *Table) WriteCSVTo(w io.Writer) error {
+	cw := NewCSVwriterTo(w)
+	if err := t.Write(cw); err != nil {
+		return err
+	}
+	return cw.CloseE()
+}
+
+// Write writes the header and rows of the table to w
+func (t *Table) Write(w *CSVwriter) error {
+	if err := w.WriteHead(strings.Join(t.Head, ",")); err != nil {
+		return err
+	}
+	ln := make([]interface{}, len(t.cols))
+	for i := 0; i < t.nrow; i++ {
+		for j, c := range t.cols {
+			if c.numeric() {
+				ln[j] = c.f[i]
+			} else {
+				ln[j] = c.s[i]
+			}
+		}
+		if err := w.WriteLine(ln...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NRows returns the number of rows
+func (t *Table) NRows() int { return t.nrow }
+
+// NCols returns the number of columns
+func (t *Table) NCols() int { return len(t.cols) }
+
+// Index returns the position of a named column, -1 if not found
+func (t *Table) Index(name string) int {
+	for j, h := range t.Head {
+		if h == name {
+			return j
+		}
+	}
+	return -1
+}
+
+// IsNumeric returns true if the named column is numeric
+func (t *Table) IsNumeric(name string) bool {
+	j := t.Index(name)
+	return j >= 0 && t.cols[j].numeric()
+}
+
+// Floats returns the numeric values of a column (nil if missing or text)
+func (t *Table) Floats(name string) []float64 {
+	if j := t.Index(name); j >= 0 {
+		return t.cols[j].f
+	}
+	return nil
+}
+
+// Strings returns a column as strings (numeric columns are formatted)
+func (t *Table) Strings(name string) []string {
+	j := t.Index(name)
+	if j < 0 {
+		return nil
+	}
+	if !t.cols[j].numeric() {
+		return t.cols[j].s
+	}
+	s := make([]string, t.nrow)
+	for i := range s {
+		s[i] = t.cols[j].key(i)
+	}
+	return s
+}
+
+func (t *Table) indices(names []string) ([]int, error) {
+	jj := make([]int, len(names))
+	for k, n := range names {
+		if jj[k] = t.Index(n); jj[k] < 0 {
+			return nil, fmt.Errorf("Table: column %s not found", n)
+		}
+	}
+	return jj, nil
+}
+
+func (t *Table) rows(ii []int) *Table {
+	o := &Table{Head: append([]string(nil), t.Head...), cols: make([]tcol, len(t.cols)), nrow: len(ii)}
+	for j, c := range t.cols {
+		o.cols[j] = c.pick(ii)
+	}
+	return o
+}
+
+// Select returns a new table of the given columns, in the given order (column data is shared)
+func (t *Table) Select(names ...string) (*Table, error) {
+	jj, err := t.indices(names)
+	if err != nil {
+		return nil, err
+	}
+	o := &Table{Head: append([]string(nil), names...), cols: make([]tcol, len(jj)), nrow: t.nrow}
+	for k, j := range jj {
+		o.cols[k] = t.cols[j]
+	}
+	return o, nil
+}
+
+// Row gives a predicate access to the cells of a single table row
+type Row struct {
+	t *Table
+	i int
+}
+
+// Float returns the numeric value of a column in the row (NaN if missing or text)
+func (r Row) Float(name string) float64 {
+	if j := r.t.Index(name); j >= 0 && r.t.cols[j].numeric() {
+		return r.t.cols[j].f[r.i]
+	}
+	return math.NaN()
+}
+
+// String returns the value of a column in the row as a string
+func (r Row) String(name string) string {
+	if j := r.t.Index(name); j >= 0 {
+		return r.t.cols[j].key(r.i)
+	}
+	return ""
+}
+
+// Filter returns a new table holding the rows for which keep returns true
+func (t *Table) Filter(keep func(r Row) bool) *Table {
+	var ii []int
+	for i := 0; i < t.nrow; i++ {
+		if keep(Row{t, i}) {
+			ii = append(ii, i)
+		}
+	}
+	return t.rows(ii)
+}
+
+// SortKey is a sort column and direction
+type SortKey struct {
+	Col  string
+	Desc bool
+}
+
+// Sort returns a new table stably sorted by the given keys. Numeric columns sort
+// numerically (NaN last), text columns lexicographically.
+func (t *Table) Sort(keys ...SortKey) (*Table, error) {
+	names := make([]string, len(keys))
+	for k, sk := range keys {
+		names[k] = sk.Col
+	}
+	jj, err := t.indices(names)
+	if err != nil {
+		return nil, err
+	}
+	ii := make([]int, t.nrow)
+	for i := range ii {
+		ii[i] = i
+	}
+	sort.SliceStable(ii, func(a, b int) bool {
+		for k, j := range jj {
+			c, nan := compareCells(t.cols[j], ii[a], ii[b])
+			if c == 0 {
+				continue
+			}
+			if keys[k].Desc && !nan {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return t.rows(ii), nil
+}
+
+// compareCells returns -1, 0 or 1; nan is true when the order is set by a NaN (always sorted last)
+func compareCells(c tcol, a, b int) (_ int, nan bool) {
+	if c.numeric() {
+		fa, fb := c.f[a], c.f[b]
+		switch {
+		case math.IsNaN(fa) && math.IsNaN(fb):
+			return 0, true
+		case math.IsNaN(fa):
+			return 1, true
+		case math.IsNaN(fb):
+			return -1, true
+		case fa < fb:
+			return -1, false
+		case fa > fb:
+			return 1, false
+		}
+		return 0, false
+	}
+	return strings.Compare(c.s[a], c.s[b]), false
+}
+
+// JoinType specifies how unmatched rows are handled by Join
+type JoinType int
+
+const (
+	// InnerJoin keeps only rows with a match in both tables
+	InnerJoin JoinType = iota
+	// LeftJoin keeps every row of the left table, unmatched cells are NaN or ""
+	LeftJoin
+)
+
+// Join combines t with right on equal values of the key columns, which must exist in both tables.
+// Non-key columns of right that share a name with a column of t are suffixed with "_right", or
+// "_right2", "_right3", ... if that name is taken too.
+func (t *Table) Join(right *Table, on []string, how JoinType) (*Table, error) {
+	lj, err := t.indices(on)
+	if err != nil {
+		return nil, err
+	}
+	rj, err := right.indices(on)
+	if err != nil {
+		return nil, fmt.Errorf("Join right table: %v", err)
+	}
+
+	idx := make(map[string][]int, right.nrow)
+	for i := 0; i < right.nrow; i++ {
+		k := rowKey(right.cols, rj, i)
+		idx[k] = append(idx[k], i)
+	}
+
+	var li, ri []int
+	for i := 0; i < t.nrow; i++ {
+		if m, ok := idx[rowKey(t.cols, lj, i)]; ok {
+			for _, r := range m {
+				li = append(li, i)
+				ri = append(ri, r)
+			}
+		} else if how == LeftJoin {
+			li = append(li, i)
+			ri = append(ri, -1)
+		}
+	}
+
+	o := t.rows(li)
+	iskey := make(map[int]bool, len(rj))
+	for _, j := range rj {
+		iskey[j] = true
+	}
+	for j, c := range right.cols {
+		if iskey[j] {
+			continue
+		}
+		n := right.Head[j]
+		if o.Index(n) >= 0 {
+			s := n + "_right"
+			for k := 2; o.Index(s) >= 0; k++ {
+				s = fmt.Sprintf("%s_right%d", n, k)
+			}
+			n = s
+		}
+		o.Head = append(o.Head, n)
+		o.cols = append(o.cols, c.pick(ri))
+	}
+	return o, nil
+}
+
+func rowKey(cols []tcol, jj []int, i int) string {
+	if len(jj) == 1 {
+		return cols[jj[0]].key(i)
+	}
+	k := make([]string, len(jj))
+	for n, j := range jj {
+		k[n] = cols[j].key(i)
+	}
+	return strings.Join(k, "\x00")
+}
+
+// AggFunc is an aggregation applied to grouped numeric columns
+type AggFunc int
+
+const (
+	AggSum AggFunc = iota
+	AggMean
+	AggCount
+	AggMin
+	AggMax
+)
+
+var aggNames = [...]string{"sum", "mean", "count", "min", "max"}
+
+func (a AggFunc) String() string {
+	if a < 0 || int(a) >= len(aggNames) {
+		return fmt.Sprintf("AggFunc(%d)", a)
+	}
+	return aggNames[a]
+}
+
+// Agg describes an aggregated output column of GroupBy, named <Col>_<Fn>
+type Agg struct {
+	Col string
+	Fn  AggFunc
+}
+
+// GroupBy groups rows on equal values of the key columns, in order of first appearance, and
+// aggregates numeric columns. NaN values are ignored; AggCount returns the number of non-NaN values.
+func (t *Table) GroupBy(keys []string, aggs ...Agg) (*Table, error) {
+	kj, err := t.indices(keys)
+	if err != nil {
+		return nil, err
+	}
+	aj := make([]int, len(aggs))
+	for k, a := range aggs {
+		if aj[k] = t.Index(a.Col); aj[k] < 0 {
+			return nil, fmt.Errorf("GroupBy: column %s not found", a.Col)
+		}
+		if !t.cols[aj[k]].numeric() && a.Fn != AggCount {
+			return nil, fmt.Errorf("GroupBy: cannot %v text column %s", a.Fn, a.Col)
+		}
+	}
+
+	gidx, first, members := make(map[string]int), []int{}, [][]int{}
+	for i := 0; i < t.nrow; i++ {
+		k := rowKey(t.cols, kj, i)
+		g, ok := gidx[k]
+		if !ok {
+			g = len(first)
+			gidx[k] = g
+			first = append(first, i)
+			members = append(members, nil)
+		}
+		members[g] = append(members[g], i)
+	}
+
+	o := &Table{nrow: len(first)}
+	for k, j := range kj {
+		o.Head = append(o.Head, keys[k])
+		o.cols = append(o.cols, t.cols[j].pick(first))
+	}
+	for k, a := range aggs {
+		c, v := t.cols[aj[k]], make([]float64, len(members))
+		for g, ii := range members {
+			v[g] = aggregate(c, ii, a.Fn)
+		}
+		o.Head = append(o.Head, a.Col+"_"+a.Fn.String())
+		o.cols = append(o.cols, tcol{f: v})
+	}
+	return o, nil
+}
+
+func aggregate(c tcol, ii []int, fn AggFunc) float64 {
+	if !c.numeric() { // AggCount only
+		n := 0
+		for _, i := range ii {
+			if len(c.s[i]) > 0 {
+				n++
+			}
+		}
+		return float64(n)
+	}
+	s, n, mn, mx := 0., 0, math.Inf(1), math.Inf(-1)
+	for _, i := range ii {
+		v := c.f[i]
+		if math.IsNaN(v) {
+			continue
+		}
+		s += v
+		n++
+		mn = math.Min(mn, v)
+		mx = math.Max(mx, v)
+	}
+	switch fn {
+	case AggCount:
+		return float64(n)
+	case AggSum:
+		return s
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	switch fn {
+	case AggMean:
+		return s / float64(n)
+	case AggMin:
+		return mn
+	case AggMax:
+		return mx
+	}
+	return math.NaN()
+}
@@ -0,0 +1,111 @@
+package mmio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	const in = "site,year,q\nb,2001,2\na,2001,NA\nb,2000,4\na,2000,1\n"
+	right, err := ReadCsvTableFrom(strings.NewReader("site,name,q\na,Alpha,10\nc,Gamma,30\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		op   func(t *Table) (*Table, error)
+		want string
+	}{
+		{"read and write", func(t *Table) (*Table, error) { return t, nil }, "site,year,q\nb,2001,2\na,2001,NaN\nb,2000,4\na,2000,1\n"},
+		{"select", func(t *Table) (*Table, error) { return t.Select("q", "site") }, "q,site\n2,b\nNaN,a\n4,b\n1,a\n"},
+		{"filter", func(t *Table) (*Table, error) {
+			return t.Filter(func(r Row) bool { return r.String("site") == "a" && r.Float("year") > 2000 }), nil
+		}, "site,year,q\na,2001,NaN\n"},
+		{"sort, NaN last", func(t *Table) (*Table, error) { return t.Sort(SortKey{Col: "q"}) }, "site,year,q\na,2000,1\nb,2001,2\nb,2000,4\na,2001,NaN\n"},
+		{"sort descending, NaN last", func(t *Table) (*Table, error) { return t.Sort(SortKey{Col: "q", Desc: true}) }, "site,year,q\nb,2000,4\nb,2001,2\na,2000,1\na,2001,NaN\n"},
+		{"sort two keys", func(t *Table) (*Table, error) { return t.Sort(SortKey{Col: "site"}, SortKey{Col: "year"}) }, "site,year,q\na,2000,1\na,2001,NaN\nb,2000,4\nb,2001,2\n"},
+		{"inner join", func(t *Table) (*Table, error) { return t.Join(right, []string{"site"}, InnerJoin) }, "site,year,q,name,q_right\na,2001,NaN,Alpha,10\na,2000,1,Alpha,10\n"},
+		{"left join", func(t *Table) (*Table, error) { return t.Join(right, []string{"site"}, LeftJoin) },
+			"site,year,q,name,q_right\nb,2001,2,,NaN\na,2001,NaN,Alpha,10\nb,2000,4,,NaN\na,2000,1,Alpha,10\n"},
+		{"group by", func(t *Table) (*Table, error) {
+			return t.GroupBy([]string{"site"}, Agg{"q", AggSum}, Agg{"q", AggMean}, Agg{"q", AggCount}, Agg{"year", AggMax})
+		}, "site,q_sum,q_mean,q_count,year_max\nb,6,3,2,2001\na,1,1,1,2001\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb, err := ReadCsvTableFrom(strings.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := tt.op(tb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := o.WriteCSVTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTableErrors(t *testing.T) {
+	tb, err := ReadCsvTableFrom(strings.NewReader("site,q\na,1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tb.Select("missing"); err == nil {
+		t.Error("Select of a missing column: no error")
+	}
+	if _, err := tb.GroupBy([]string{"q"}, Agg{"site", AggSum}); err == nil {
+		t.Error("sum of a text column: no error")
+	}
+	if err := tb.AddFloats("x", []float64{1, 2}); err == nil {
+		t.Error("AddFloats of the wrong length: no error")
+	}
+	if !tb.IsNumeric("q") || tb.IsNumeric("site") {
+		t.Error("column types not detected")
+	}
+}
+
+func TestTableJoinSuffix(t *testing.T) {
+	tests := []struct {
+		name, left, right string
+		want              string // header of the join
+	}{
+		{"suffixed", "k,q\n1,1\n", "k,q\n1,2\n", "k,q,q_right"},
+		{"suffix taken on the left", "k,q,q_right\n1,1,1\n", "k,q\n1,2\n", "k,q,q_right,q_right2"},
+		{"suffix taken on the right", "k,q\n1,1\n", "k,q_right,q\n1,2,3\n", "k,q,q_right,q_right2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := ReadCsvTableFrom(strings.NewReader(tt.left))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := ReadCsvTableFrom(strings.NewReader(tt.right))
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := l.Join(r, []string{"k"}, InnerJoin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(o.Head, ","); got != tt.want {
+				t.Errorf("header %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggFuncString(t *testing.T) {
+	for a, want := range map[AggFunc]string{AggSum: "sum", AggMax: "max", AggFunc(-1): "AggFunc(-1)", AggFunc(9): "AggFunc(9)"} {
+		if got := a.String(); got != want {
+			t.Errorf("AggFunc(%d).String() = %q, want %q", int(a), got, want)
+		}
+	}
+}
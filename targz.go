@@ -137,7 +137,9 @@ func ExtractTarGZ(fp string) (string, error) {
 	defer gzf.Close()
 
 	odir := strings.Replace(fp, ".tar.gz", string(47), -1)
-	MakeDir(odir)
+	if _, err := MakeDirE(odir); err != nil {
+		return "", err
+	}
 
 	tarReader := tar.NewReader(gzf)
 	for {
@@ -154,9 +156,8 @@ func ExtractTarGZ(fp string) (string, error) {
 		case tar.TypeDir:
 			os.Mkdir(name, 0755)
 		case tar.TypeReg:
-			dir := GetFileDir(name)
-			if !DirExists(dir) {
-				MakeDir(dir)
+			if _, err := MakeDirE(GetFileDir(name)); err != nil {
+				return "", err
 			}
 			f, err := os.Create(name)
 			if err != nil {
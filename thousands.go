@@ -1,6 +1,9 @@
 package mmio
 
-import "strconv"
+import (
+	"math"
+	"strconv"
+)
 
 // Thousands returns a string integer with thousand separators
 // from: https://stackoverflow.com/questions/13020308/how-to-fmt-printf-an-integer-with-thousands-comma
@@ -22,3 +25,16 @@ func Thousands(n int64) string {
 		}
 	}
 }
+
+// HumanSize returns a byte count in binary units (1 KB = 1024 B) with one decimal, e.g. "512 B", "1.5 KB", "12.3 GB"
+func HumanSize(n int64) string {
+	const units = "KMGTPE"
+	if n < 1024 && n > -1024 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	f, i := float64(n)/1024, 0
+	for ; math.Round(math.Abs(f)*10) >= 10240 && i < len(units)-1; i++ { // as rounded to one decimal
+		f /= 1024
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64) + " " + units[i:i+1] + "B"
+}
@@ -0,0 +1,44 @@
+package mmio
+
+import "testing"
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{-1536, "-1.5 KB"},
+		{1<<20 - 1, "1.0 MB"},
+		{1<<20 - 52, "1023.9 KB"},
+		{12*1<<30 + 300*1<<20, "12.3 GB"},
+		{1<<40 - 1, "1.0 TB"},
+		{1 << 62, "4.0 EB"},
+	}
+	for _, tt := range tests {
+		if got := HumanSize(tt.n); got != tt.want {
+			t.Errorf("HumanSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestThousands(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{-1234567, "-1,234,567"},
+	}
+	for _, tt := range tests {
+		if got := Thousands(tt.n); got != tt.want {
+			t.Errorf("Thousands(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
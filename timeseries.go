@@ -1,6 +1,9 @@
 package mmio
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 // TimeSeries is a collection of temporal data
 type TimeSeries map[time.Time]float64
@@ -38,3 +41,48 @@ func MinMaxTimeseries(ts TimeSeries) (_, _ time.Time) {
 	}
 	return tn, tx
 }
+
+// FlaggedValue is a value with its data-quality flag (e.g., estimated, ice-affected, provisional)
+type FlaggedValue struct {
+	V     float64
+	Flag  string
+	Extra map[string]string // named qualifier columns (see ReadCsvDateFloatExtra)
+}
+
+// FlaggedSeries is a collection of temporal data with flags
+type FlaggedSeries map[time.Time]FlaggedValue
+
+// Values returns the timeseries, ignoring flags
+func (fs FlaggedSeries) Values() TimeSeries {
+	ts := make(TimeSeries, len(fs))
+	for dt, fv := range fs {
+		ts[dt] = fv.V
+	}
+	return ts
+}
+
+// Filter returns the timeseries of values whose flag is kept
+func (fs FlaggedSeries) Filter(keep func(flag string) bool) TimeSeries {
+	ts := make(TimeSeries, len(fs))
+	for dt, fv := range fs {
+		if keep(fv.Flag) {
+			ts[dt] = fv.V
+		}
+	}
+	return ts
+}
+
+// Mask returns the full timeseries with values set to NaN where flagged with any of the given flags
+func (fs FlaggedSeries) Mask(flags ...string) TimeSeries {
+	ts := make(TimeSeries, len(fs))
+	for dt, fv := range fs {
+		ts[dt] = fv.V
+		for _, f := range flags {
+			if fv.Flag == f {
+				ts[dt] = math.NaN()
+				break
+			}
+		}
+	}
+	return ts
+}
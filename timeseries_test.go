@@ -0,0 +1,92 @@
+package mmio
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCsvDateFloatFlag(t *testing.T) {
+	d := func(dd int) time.Time { return time.Date(2020, 1, dd, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name    string
+		in      string
+		extra   []string
+		want    FlaggedSeries
+		wantErr bool
+	}{
+		{"flags", "date,value,flag\n2020-01-01,1.5,E\n2020-01-02,2,\n",
+			nil, FlaggedSeries{d(1): {V: 1.5, Flag: "E"}, d(2): {V: 2}}, false},
+		{"no flag column", "date,value\n2020-01-01,1\n",
+			nil, FlaggedSeries{d(1): {V: 1}}, false},
+		{"missing value keeps its flag", "date,value,flag\n2020-01-01,,I\n2020-01-02,NA,B\n",
+			nil, FlaggedSeries{d(1): {V: math.NaN(), Flag: "I"}, d(2): {V: math.NaN(), Flag: "B"}}, false},
+		{"extra columns", "date,value,flag,Grade,approval\n2020-01-01,1,A,good,final\n2020-01-02,2,,poor\n",
+			[]string{"grade", "approval"}, FlaggedSeries{
+				d(1): {V: 1, Flag: "A", Extra: map[string]string{"grade": "good", "approval": "final"}},
+				d(2): {V: 2, Extra: map[string]string{"grade": "poor"}},
+			}, false},
+		{"missing extra column", "date,value,flag\n2020-01-01,1,A\n", []string{"grade"}, nil, true},
+		{"bad value", "date,value,flag\n2020-01-01,x,A\n", nil, nil, true},
+		{"bad date", "date,value,flag\nsoon,1,A\n", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCsvDateFloatExtraFrom(strings.NewReader(tt.in), tt.extra...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if !flaggedEqual(got, tt.want) {
+				t.Errorf("read %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlaggedSeriesRoundTrip(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs := FlaggedSeries{
+		t0:                  {V: 1.25},
+		t0.Add(time.Hour):   {V: math.NaN(), Flag: "M"},
+		t0.AddDate(0, 0, 1): {V: -3, Flag: "E"},
+	}
+	fp := filepath.Join(t.TempDir(), "q.csv")
+	if err := WriteCsvDateFloatFlag(fp, fs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadCsvDateFloatFlag(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flaggedEqual(got, fs) {
+		t.Errorf("read back %v, want %v", got, fs)
+	}
+
+	if v := fs.Filter(func(f string) bool { return f != "E" }); len(v) != 2 {
+		t.Errorf("Filter kept %v", v)
+	}
+	m := fs.Mask("E")
+	if len(m) != 3 || !math.IsNaN(m[t0.AddDate(0, 0, 1)]) || m[t0] != 1.25 {
+		t.Errorf("Mask = %v", m)
+	}
+}
+
+// flaggedEqual compares flagged series, NaN values being equal
+func flaggedEqual(a, b FlaggedSeries) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for t, va := range a {
+		vb, ok := b[t]
+		if !ok || va.Flag != vb.Flag || !reflect.DeepEqual(va.Extra, vb.Extra) {
+			return false
+		}
+		if va.V != vb.V && !(math.IsNaN(va.V) && math.IsNaN(vb.V)) {
+			return false
+		}
+	}
+	return true
+}
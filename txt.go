@@ -4,19 +4,36 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"os"
+	"log"
 	"strings"
 )
 
 // TXTwriter general text writer
 type TXTwriter struct {
-	file   *os.File
+	file   outputFile
 	Writer *bufio.Writer
 }
 
 // NewTXTwriter constructor
 func NewTXTwriter(fp string) (*TXTwriter, error) {
-	file, err := os.Create(fp)
+	return newTXTwriter(fp, false)
+}
+
+// NewTXTwriterAtomic constructs a TXTwriter that only replaces fp once Close succeeds (see AtomicWrites)
+func NewTXTwriterAtomic(fp string) (*TXTwriter, error) {
+	return newTXTwriter(fp, true)
+}
+
+// NewTXTwriterTo constructs a TXTwriter over w; Close flushes but does not close w
+func NewTXTwriterTo(w io.Writer) *TXTwriter {
+	return &TXTwriter{
+		file:   nopOutput{w},
+		Writer: bufio.NewWriter(w),
+	}
+}
+
+func newTXTwriter(fp string, atomic bool) (*TXTwriter, error) {
+	file, err := createOutput(fp, atomic)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot create file: %v", err)
 	}
@@ -27,10 +44,25 @@ func NewTXTwriter(fp string) (*TXTwriter, error) {
 	return nt, nil
 }
 
-// Close closes TXTwriter
+// Close closes TXTwriter; errors are logged, use CloseE to handle them
 func (w *TXTwriter) Close() {
-	w.Writer.Flush()
-	w.file.Close()
+	if err := w.CloseE(); err != nil {
+		log.Println(err)
+	}
+}
+
+// CloseE flushes and closes TXTwriter, returning any write or close error
+func (w *TXTwriter) CloseE() error {
+	if err := w.Writer.Flush(); err != nil {
+		w.file.Abort()
+		return fmt.Errorf("Cannot write to file: %v", err)
+	}
+	return w.file.Close()
+}
+
+// Abort closes TXTwriter discarding its output (in atomic mode, the target file is left untouched)
+func (w *TXTwriter) Abort() error {
+	return w.file.Abort()
 }
 
 // Write is a general textfile writer method for TXTwriter
@@ -62,13 +94,17 @@ func (w *TXTwriter) WriteBytes(b []byte) error {
 
 // ReadTextLines reads and returns string lines from binary file
 func ReadTextLines(fp string) ([]string, error) {
-	file, err := os.Open(fp)
+	file, err := openRead(fp)
 	if err != nil {
 		return nil, fmt.Errorf("ReadTextLines: %v", err)
 	}
 	defer file.Close()
+	return ReadTextLinesFrom(file)
+}
 
-	reader, a := bufio.NewReader(file), make([]string, 0)
+// ReadTextLinesFrom reads r to its end and returns its lines, trimmed of surrounding white space
+func ReadTextLinesFrom(r io.Reader) ([]string, error) {
+	reader, a := bufio.NewReader(r), make([]string, 0)
 	for {
 		line, err := reader.ReadString('\n')
 		// line, _, err := reader.ReadLine()
@@ -0,0 +1,249 @@
+package mmio
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WatchOp is the kind of change reported by a Watcher
+type WatchOp int
+
+const (
+	WatchCreated WatchOp = iota + 1
+	WatchModified
+	WatchRemoved
+	WatchError // listing the directory failed; the watcher keeps polling
+)
+
+func (o WatchOp) String() string {
+	switch o {
+	case WatchCreated:
+		return "created"
+	case WatchModified:
+		return "modified"
+	case WatchRemoved:
+		return "removed"
+	case WatchError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// WatchEvent is a change to a watched file; for WatchRemoved, File is its last known state
+type WatchEvent struct {
+	Op   WatchOp
+	File FileEntry
+	Err  error // set with WatchError
+}
+
+// Clock is the time source of a Watcher, replaceable in tests (see ManualClock)
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Watcher polls a directory through ListFiles and reports the files that appear, change or
+// disappear. Created and modified files are only reported once their size and modification
+// time have stopped changing for Settle, so that files still being written are not picked up.
+type Watcher struct {
+	Dir      string
+	Options  ListOptions   // files watched (patterns, depth, exclusions, ...)
+	Interval time.Duration // time between polls (<= 0: DefaultWatchInterval)
+	Settle   time.Duration // time a file must stay unchanged before it is reported (0: one poll)
+	Existing bool          // report files present at start as created (otherwise they are the baseline)
+	Clock    Clock         // defaults to the system clock
+}
+
+// DefaultWatchInterval is the polling interval of a Watcher whose Interval is not set
+const DefaultWatchInterval = time.Second
+
+// NewWatcher returns a Watcher polling dir every interval for files matching patterns (all files if none)
+func NewWatcher(dir string, interval time.Duration, patterns ...string) *Watcher {
+	return &Watcher{Dir: dir, Options: ListOptions{Patterns: patterns}, Interval: interval}
+}
+
+type watchPending struct {
+	op    WatchOp
+	file  FileEntry
+	since time.Time
+}
+
+// Watch starts polling and returns the event channel, which is closed once ctx is done
+func (w *Watcher) Watch(ctx context.Context) <-chan WatchEvent {
+	clk := w.Clock
+	if clk == nil {
+		clk = systemClock{}
+	}
+	intvl := w.Interval
+	if intvl <= 0 {
+		intvl = DefaultWatchInterval
+	}
+	ch := make(chan WatchEvent)
+	go func() {
+		defer close(ch)
+		seen := make(map[string]FileEntry)
+		pend := make(map[string]watchPending)
+		emit := func(e WatchEvent) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		first := !w.Existing
+		for {
+			listed, ok := w.poll(clk.Now(), seen, pend, first, emit)
+			if !ok {
+				return
+			}
+			if listed {
+				first = false // the baseline is only taken from a successful listing
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-clk.After(intvl):
+			}
+		}
+	}()
+	return ch
+}
+
+// poll lists the directory once and emits the resulting events. listed reports whether the
+// listing succeeded; ok is false once ctx is done.
+func (w *Watcher) poll(now time.Time, seen map[string]FileEntry, pend map[string]watchPending, baseline bool, emit func(WatchEvent) bool) (listed, ok bool) {
+	es, err := ListFiles(w.Dir, w.Options)
+	if err != nil {
+		return false, emit(WatchEvent{Op: WatchError, Err: err})
+	}
+	if baseline {
+		for _, e := range es {
+			seen[e.Rel] = e
+		}
+		return true, true
+	}
+
+	same := func(a, b FileEntry) bool { return a.Size == b.Size && a.ModTime.Equal(b.ModTime) }
+	cur := make(map[string]bool, len(es))
+	for _, e := range es {
+		cur[e.Rel] = true
+		if p, ok := pend[e.Rel]; ok {
+			if !same(p.file, e) {
+				pend[e.Rel] = watchPending{p.op, e, now}
+			} else if now.Sub(p.since) >= w.Settle {
+				delete(pend, e.Rel)
+				seen[e.Rel] = e
+				if !emit(WatchEvent{Op: p.op, File: e}) {
+					return true, false
+				}
+			}
+			continue
+		}
+		if s, ok := seen[e.Rel]; !ok {
+			pend[e.Rel] = watchPending{WatchCreated, e, now}
+		} else if !same(s, e) {
+			pend[e.Rel] = watchPending{WatchModified, e, now}
+		}
+	}
+
+	for rel := range pend {
+		if !cur[rel] {
+			delete(pend, rel)
+		}
+	}
+	for _, rel := range sortedKeys(seen) {
+		if !cur[rel] {
+			e := seen[rel]
+			delete(seen, rel)
+			if !emit(WatchEvent{Op: WatchRemoved, File: e}) {
+				return true, false
+			}
+		}
+	}
+	return true, true
+}
+
+func sortedKeys(m map[string]FileEntry) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+// ManualClock is a Clock that only moves when advanced, for testing code using a Watcher
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewManualClock returns a ManualClock set to t
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{now: t}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time once the clock has been advanced by d
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{c.now.Add(d), ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing the After channels that are due
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	k := 0
+	for _, w := range c.waiters {
+		if !w.at.After(c.now) {
+			w.ch <- c.now
+		} else {
+			c.waiters[k] = w
+			k++
+		}
+	}
+	c.waiters = c.waiters[:k]
+}
+
+// BlockUntil waits until n callers are blocked in After, so that a following Advance is not missed
+func (c *ManualClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		k := len(c.waiters)
+		c.mu.Unlock()
+		if k >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,111 @@
+package mmio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	write := func(dir, name, s string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name     string
+		existing bool
+		setup    func(dir string) // before the watcher starts
+		change   func(dir string) // after the first poll
+		want     []string
+	}{
+		{"baseline", false,
+			func(dir string) { write(dir, "a.txt", "a") },
+			func(dir string) {},
+			nil},
+		{"existing", true,
+			func(dir string) { write(dir, "a.txt", "a") },
+			func(dir string) {},
+			[]string{"created a.txt"}},
+		{"created", false,
+			func(dir string) { write(dir, "a.txt", "a") },
+			func(dir string) { write(dir, "b.txt", "b") },
+			[]string{"created b.txt"}},
+		{"modified", false,
+			func(dir string) { write(dir, "a.txt", "a") },
+			func(dir string) { write(dir, "a.txt", "aaa") },
+			[]string{"modified a.txt"}},
+		{"removed", false,
+			func(dir string) { write(dir, "a.txt", "a") },
+			func(dir string) { os.Remove(filepath.Join(dir, "a.txt")) },
+			[]string{"removed a.txt"}},
+		{"baseline after failed listing", false,
+			func(dir string) { os.Remove(dir) },
+			func(dir string) { os.Mkdir(dir, 0755); write(dir, "a.txt", "a") },
+			[]string{"error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "in")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			tt.setup(dir)
+			clk := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+			w := &Watcher{Dir: dir, Interval: time.Second, Existing: tt.existing, Clock: clk}
+			ctx, cancel := context.WithCancel(context.Background())
+
+			var mu sync.Mutex
+			var got []string
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for e := range w.Watch(ctx) {
+					s := e.Op.String()
+					if e.Op != WatchError {
+						s += " " + e.File.Rel
+					}
+					mu.Lock()
+					got = append(got, s)
+					mu.Unlock()
+				}
+			}()
+
+			clk.BlockUntil(1) // first poll done
+			tt.change(dir)
+			for i := 0; i < 3; i++ {
+				clk.Advance(w.Interval)
+				clk.BlockUntil(1)
+			}
+			cancel()
+			<-done
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcherDefaultInterval(t *testing.T) {
+	clk := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	w := &Watcher{Dir: t.TempDir(), Clock: clk}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := w.Watch(ctx)
+
+	clk.BlockUntil(1) // with Interval 0, After(0) would fire at once and no waiter would be left
+	clk.Advance(DefaultWatchInterval / 2)
+	clk.mu.Lock()
+	n := len(clk.waiters)
+	clk.mu.Unlock()
+	if n != 1 {
+		t.Errorf("poll fired after %v, want %v", DefaultWatchInterval/2, DefaultWatchInterval)
+	}
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,195 @@
+package mmio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// WorkspacePrefix starts the name of every run directory created by NewWorkspace
+const WorkspacePrefix = "mmrun-"
+
+// WorkspaceOptions controls NewWorkspace
+type WorkspaceOptions struct {
+	KeepOnFailure bool          // Close leaves the directory in place once Fail has been called
+	StaleAge      time.Duration // remove unlocked workspaces under root older than this on start (0: never)
+}
+
+// Workspace is a uniquely named scratch directory for a run, "<root>/mmrun-yymmddhhmmss-<random>",
+// removed by Close. It holds a DirLock for its lifetime, so that stale cleanup by other
+// processes skips it. Open workspaces are also closed by CloseWorkspaces (see HandleSignals).
+type Workspace struct {
+	Dir  string
+	opt  WorkspaceOptions
+	lock *DirLock
+	once sync.Once
+	mu   sync.Mutex
+	fail bool
+}
+
+var openWorkspaces = struct {
+	sync.Mutex
+	m map[*Workspace]bool
+}{m: make(map[*Workspace]bool)}
+
+// NewWorkspace creates a new run directory under root (created if missing)
+func NewWorkspace(root string, opt WorkspaceOptions) (*Workspace, error) {
+	if _, err := MakeDirE(root); err != nil {
+		return nil, fmt.Errorf("NewWorkspace: %v", err)
+	}
+	if opt.StaleAge > 0 {
+		if _, err := CleanStaleWorkspaces(root, opt.StaleAge); err != nil {
+			return nil, fmt.Errorf("NewWorkspace: %v", err)
+		}
+	}
+	dir, err := os.MkdirTemp(root, WorkspacePrefix+MMtime(time.Now())+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("NewWorkspace: %v", err)
+	}
+	w := &Workspace{Dir: dir, opt: opt}
+	if w.lock, err = LockDir(dir, 0); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("NewWorkspace: %v", err)
+	}
+	openWorkspaces.Lock()
+	openWorkspaces.m[w] = true
+	openWorkspaces.Unlock()
+	return w, nil
+}
+
+// CloseWorkspaces closes every Workspace not closed yet
+func CloseWorkspaces() error {
+	openWorkspaces.Lock()
+	ws := make([]*Workspace, 0, len(openWorkspaces.m))
+	for w := range openWorkspaces.m {
+		ws = append(ws, w)
+	}
+	openWorkspaces.Unlock()
+	var errs []error
+	for _, w := range ws {
+		errs = append(errs, w.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// HandleSignals installs a single handler for sigs (SIGINT and SIGTERM if none are given) that
+// closes all open workspaces and then cancels the returned context; shutting down, or exiting, is
+// left to the caller. The handler is removed once the context is done, restoring the default
+// behaviour of a second signal. Without HandleSignals, mmio does not intercept signals.
+//
+//	ctx, stop := mmio.HandleSignals(context.Background())
+//	defer stop()
+func HandleSignals(parent context.Context, sigs ...os.Signal) (context.Context, context.CancelFunc) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		defer signal.Stop(ch)
+		select {
+		case s := <-ch:
+			if err := CloseWorkspaces(); err != nil {
+				log.Printf("HandleSignals: %v: %v", s, err)
+			}
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Path joins elem to the workspace directory
+func (w *Workspace) Path(elem ...string) string {
+	return filepath.Join(append([]string{w.Dir}, elem...)...)
+}
+
+// MakeDir creates the subdirectory elem of the workspace, returning its path
+func (w *Workspace) MakeDir(elem ...string) (string, error) {
+	return MakeDirE(w.Path(elem...))
+}
+
+// Create creates (truncates) the file elem of the workspace, along with its missing parents
+func (w *Workspace) Create(elem ...string) (*os.File, error) {
+	fp := w.Path(elem...)
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return nil, pathError("Workspace.Create", fp, err)
+	}
+	return os.Create(fp)
+}
+
+// Fail marks the run as failed: with KeepOnFailure, Close will leave the directory for inspection
+func (w *Workspace) Fail() {
+	w.mu.Lock()
+	w.fail = true
+	w.mu.Unlock()
+}
+
+// Close releases the workspace and removes its directory, unless the run failed and
+// KeepOnFailure is set, in which case the directory kept is logged. Only the first call has an effect.
+func (w *Workspace) Close() error {
+	var err error
+	w.once.Do(func() {
+		openWorkspaces.Lock()
+		delete(openWorkspaces.m, w)
+		openWorkspaces.Unlock()
+		if w.lock != nil {
+			w.lock.Close() // before removal: open files cannot be deleted on Windows
+		}
+		w.mu.Lock()
+		keep := w.fail && w.opt.KeepOnFailure
+		w.mu.Unlock()
+		if keep {
+			log.Printf("Workspace: run failed, kept %s", w.Dir)
+		} else if err = os.RemoveAll(w.Dir); err != nil {
+			err = pathError("Workspace.Close", w.Dir, err)
+		}
+	})
+	return err
+}
+
+// CleanStaleWorkspaces removes the workspaces under root last modified more than age ago,
+// skipping those still locked by a running process. Where file locking is unsupported, a live
+// run cannot be told from a dead one and nothing is removed. The removed directories are returned.
+func CleanStaleWorkspaces(root string, age time.Duration) ([]string, error) {
+	des, err := os.ReadDir(root)
+	if err != nil {
+		return nil, pathError("CleanStaleWorkspaces", root, err)
+	}
+	var removed []string
+	var errs []error
+	cutoff := time.Now().Add(-age)
+	for _, de := range des {
+		if !de.IsDir() || !strings.HasPrefix(de.Name(), WorkspacePrefix) {
+			continue
+		}
+		fi, err := de.Info()
+		if err != nil || fi.ModTime().After(cutoff) {
+			continue
+		}
+		dir := filepath.Join(root, de.Name())
+		l, err := LockDir(dir, 0)
+		if errors.Is(err, ErrLockTimeout) || errors.Is(err, errors.ErrUnsupported) {
+			continue // in use, or cannot tell
+		} else if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		l.Close() // workspaces are never reused, so no process can take the lock back
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, pathError("CleanStaleWorkspaces", dir, err))
+		} else {
+			removed = append(removed, dir)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,96 @@
+package mmio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorkspace(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name          string
+		keepOnFailure bool
+		fail          bool
+		wantKept      bool
+	}{
+		{"success", false, false, false},
+		{"success, keep on failure", true, false, false},
+		{"failure", false, true, false},
+		{"failure, keep on failure", true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWorkspace(root, WorkspaceOptions{KeepOnFailure: tt.keepOnFailure})
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := w.Create("sub", "a.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			if tt.fail {
+				w.Fail()
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := FileExists(w.Path("sub", "a.txt")); ok != tt.wantKept {
+				t.Fatalf("kept = %v, want %v", ok, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestCleanStaleWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	live, err := NewWorkspace(root, WorkspaceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+	dead := filepath.Join(root, WorkspacePrefix+"200101000000-1")
+	os.MkdirAll(filepath.Join(dead, "x"), 0755)
+	other := filepath.Join(root, "keep-me")
+	os.MkdirAll(other, 0755)
+	old := time.Now().Add(-48 * time.Hour)
+	for _, d := range []string{live.Dir, dead, other} {
+		os.Chtimes(d, old, old)
+	}
+
+	rm, err := CleanStaleWorkspaces(root, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rm) != 1 || rm[0] != dead {
+		t.Fatalf("removed %v, want only %s", rm, dead)
+	}
+	if !DirExists(live.Dir) || !DirExists(other) {
+		t.Fatal("a live workspace or a foreign directory was removed")
+	}
+}
+
+func TestCloseWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	var ws []*Workspace
+	for i := 0; i < 3; i++ {
+		w, err := NewWorkspace(root, WorkspaceOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws = append(ws, w)
+	}
+	if err := CloseWorkspaces(); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range ws {
+		if DirExists(w.Dir) {
+			t.Fatalf("%s not removed", w.Dir)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
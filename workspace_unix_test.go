@@ -0,0 +1,35 @@
+//go:build linux || darwin
+
+package mmio
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignals(t *testing.T) {
+	root := t.TempDir()
+	ws := make([]*Workspace, 2)
+	for i := range ws {
+		w, err := NewWorkspace(root, WorkspaceOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws[i] = w
+	}
+	ctx, stop := HandleSignals(context.Background(), syscall.SIGUSR1)
+	defer stop()
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled by the signal")
+	}
+	for _, w := range ws {
+		if DirExists(w.Dir) {
+			t.Fatalf("%s not removed", w.Dir)
+		}
+	}
+}
@@ -0,0 +1,480 @@
+package mmio
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSXreader is a minimal Excel workbook reader. Cells are returned as float64, string,
+// bool, time.Time (date-formatted numbers) or nil (blank).
+type XLSXreader struct {
+	Location *time.Location // location given to date cells (default UTC)
+
+	z        *zip.Reader
+	closer   io.Closer // the file opened by OpenXLSX; nil for OpenXLSXReaderAt
+	names    []string
+	paths    map[string]string
+	sst      []string
+	dateXf   map[int]bool
+	date1904 bool
+}
+
+type xlsxWorkbook struct {
+	Pr struct {
+		Date1904 string `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	Xfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxRow struct {
+	R     int `xml:"r,attr"`
+	Cells []struct {
+		R  string   `xml:"r,attr"`
+		T  string   `xml:"t,attr"`
+		S  int      `xml:"s,attr"`
+		V  string   `xml:"v"`
+		Is xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// OpenXLSX opens a workbook for reading
+func OpenXLSX(fp string) (*XLSXreader, error) {
+	z, err := zip.OpenReader(fp)
+	if err != nil {
+		return nil, fmt.Errorf("OpenXLSX: %v", err)
+	}
+	r, err := newXLSXreader(&z.Reader, z)
+	if err != nil {
+		z.Close()
+		return nil, fmt.Errorf("OpenXLSX %s: %v", fp, err)
+	}
+	return r, nil
+}
+
+// OpenXLSXReaderAt opens a workbook of the given size for reading from ra (e.g. a bytes.Reader or
+// an fs.FS file implementing io.ReaderAt); closing the XLSXreader does not close ra
+func OpenXLSXReaderAt(ra io.ReaderAt, size int64) (*XLSXreader, error) {
+	z, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("OpenXLSXReaderAt: %v", err)
+	}
+	r, err := newXLSXreader(z, nil)
+	if err != nil {
+		return nil, fmt.Errorf("OpenXLSXReaderAt: %v", err)
+	}
+	return r, nil
+}
+
+func newXLSXreader(z *zip.Reader, c io.Closer) (*XLSXreader, error) {
+	r := &XLSXreader{Location: time.UTC, z: z, closer: c, paths: make(map[string]string), dateXf: make(map[int]bool)}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *XLSXreader) load() error {
+	var wb xlsxWorkbook
+	if err := r.decode("xl/workbook.xml", &wb, true); err != nil {
+		return err
+	}
+	r.date1904 = wb.Pr.Date1904 == "1" || wb.Pr.Date1904 == "true"
+	var rels xlsxRels
+	if err := r.decode("xl/_rels/workbook.xml.rels", &rels, true); err != nil {
+		return err
+	}
+	targets := make(map[string]string, len(rels.Rels))
+	for _, rel := range rels.Rels {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+	for _, s := range wb.Sheets {
+		t, ok := targets[s.ID]
+		if !ok {
+			return fmt.Errorf("sheet %s has no target", s.Name)
+		}
+		r.names = append(r.names, s.Name)
+		r.paths[s.Name] = t
+	}
+
+	var sst struct {
+		SI []xlsxText `xml:"si"`
+	}
+	if err := r.decode("xl/sharedStrings.xml", &sst, false); err != nil {
+		return err
+	}
+	r.sst = make([]string, len(sst.SI))
+	for i, si := range sst.SI {
+		r.sst[i] = si.String()
+	}
+
+	var st xlsxStyles
+	if err := r.decode("xl/styles.xml", &st, false); err != nil {
+		return err
+	}
+	custom := make(map[int]string, len(st.NumFmts))
+	for _, nf := range st.NumFmts {
+		custom[nf.ID] = nf.Code
+	}
+	for i, xf := range st.Xfs {
+		id := xf.NumFmtID
+		if code, ok := custom[id]; ok {
+			r.dateXf[i] = xlsxIsDateFormat(code)
+		} else {
+			r.dateXf[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47)
+		}
+	}
+	return nil
+}
+
+// xlsxIsDateFormat checks a number format code for date/time tokens outside of quotes and brackets
+func xlsxIsDateFormat(code string) bool {
+	inq, inb := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			inq = !inq
+		case inq:
+		case c == '[':
+			inb = true
+		case c == ']':
+			inb = false
+		case inb:
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case strings.IndexByte("ymdhsYMDHS", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+func (r *XLSXreader) open(name string) (io.ReadCloser, error) {
+	for _, f := range r.z.File {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+	return nil, nil
+}
+
+func (r *XLSXreader) decode(name string, v interface{}, required bool) error {
+	rc, err := r.open(name)
+	if err != nil {
+		return err
+	}
+	if rc == nil {
+		if required {
+			return fmt.Errorf("missing %s", name)
+		}
+		return nil
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// Close closes the workbook
+func (r *XLSXreader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Sheets returns the sheet names in workbook order
+func (r *XLSXreader) Sheets() []string {
+	return append([]string(nil), r.names...)
+}
+
+// SheetRows returns the typed cells of a sheet, row by row. Rows are padded with nil to equal length.
+func (r *XLSXreader) SheetRows(sheet string) ([][]interface{}, error) {
+	p, ok := r.paths[sheet]
+	if !ok {
+		return nil, fmt.Errorf("XLSXreader: sheet %s not found", sheet)
+	}
+	rc, err := r.open(p)
+	if err != nil || rc == nil {
+		return nil, fmt.Errorf("XLSXreader: cannot open sheet %s: %v", sheet, err)
+	}
+	defer rc.Close()
+
+	var rows [][]interface{}
+	ncol, d := 0, xml.NewDecoder(rc)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("XLSXreader sheet %s: %v", sheet, err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "row" {
+			continue
+		}
+		var xr xlsxRow
+		if err := d.DecodeElement(&xr, &se); err != nil {
+			return nil, fmt.Errorf("XLSXreader sheet %s: %v", sheet, err)
+		}
+		ir := len(rows)
+		if xr.R > 0 {
+			ir = xr.R - 1
+		}
+		for len(rows) <= ir {
+			rows = append(rows, nil)
+		}
+		var row []interface{}
+		for k, c := range xr.Cells {
+			j := k
+			if len(c.R) > 0 {
+				if j, err = xlsxColIndex(c.R); err != nil {
+					return nil, fmt.Errorf("XLSXreader sheet %s: %v", sheet, err)
+				}
+			} else if len(row) > j {
+				j = len(row)
+			}
+			for len(row) <= j {
+				row = append(row, nil)
+			}
+			if row[j], err = r.cellValue(c.T, c.S, c.V, c.Is); err != nil {
+				return nil, fmt.Errorf("XLSXreader sheet %s cell %s: %v", sheet, c.R, err)
+			}
+		}
+		if len(row) > ncol {
+			ncol = len(row)
+		}
+		rows[ir] = row
+	}
+	for i := range rows {
+		for len(rows[i]) < ncol {
+			rows[i] = append(rows[i], nil)
+		}
+	}
+	return rows, nil
+}
+
+func (r *XLSXreader) cellValue(t string, s int, v string, is xlsxText) (interface{}, error) {
+	switch t {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(r.sst) {
+			return nil, fmt.Errorf("invalid shared string index %q", v)
+		}
+		return r.sst[i], nil
+	case "inlineStr":
+		return is.String(), nil
+	case "str", "e":
+		return v, nil
+	case "b":
+		return v == "1", nil
+	}
+	if len(v) == 0 {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, err
+	}
+	if r.dateXf[s] {
+		return r.serialToTime(f), nil
+	}
+	return f, nil
+}
+
+// serialToTime converts a serial date of the workbook's date system (1900 or 1904) to time
+func (r *XLSXreader) serialToTime(f float64) time.Time {
+	if r.date1904 {
+		f += 1462
+	}
+	return ExcelSerialToTime(f, r.Location)
+}
+
+// xlsxColIndex converts a cell reference (e.g., "AB12") to its 0-based column index
+func xlsxColIndex(ref string) (int, error) {
+	j := 0
+	for i := 0; i < len(ref); i++ {
+		c := ref[i]
+		if c >= 'A' && c <= 'Z' {
+			j = j*26 + int(c-'A') + 1
+		} else if c >= 'a' && c <= 'z' {
+			j = j*26 + int(c-'a') + 1
+		} else {
+			break
+		}
+	}
+	if j == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return j - 1, nil
+}
+
+// SheetArray returns a sheet as [][]string, skipping nHeaderLines rows, like LoadCsvArray.
+// Dates are formatted "2006-01-02 15:04:05" and blanks as "".
+func (r *XLSXreader) SheetArray(sheet string, nHeaderLines int) ([][]string, error) {
+	rows, err := r.SheetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	if nHeaderLines > len(rows) {
+		nHeaderLines = len(rows)
+	}
+	a := make([][]string, 0, len(rows)-nHeaderLines)
+	for _, row := range rows[nHeaderLines:] {
+		s := make([]string, len(row))
+		for j, v := range row {
+			s[j] = xlsxString(v)
+		}
+		a = append(a, s)
+	}
+	return a, nil
+}
+
+func xlsxString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
+
+// SheetColumns returns a sheet as typed columns cols[col][row], skipping nHeaderLines rows
+func (r *XLSXreader) SheetColumns(sheet string, nHeaderLines int) ([][]interface{}, error) {
+	rows, err := r.SheetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	if nHeaderLines > len(rows) {
+		nHeaderLines = len(rows)
+	}
+	rows = rows[nHeaderLines:]
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	cols := make([][]interface{}, len(rows[0]))
+	for j := range cols {
+		cols[j] = make([]interface{}, len(rows))
+		for i, row := range rows {
+			cols[j][i] = row[j]
+		}
+	}
+	return cols, nil
+}
+
+// ReadXlsxDateFloats reads a worksheet of "date,value,..." with a single header row, as ReadCsvDateFloats.
+// Date cells may be native Excel dates, serial numbers or text. Blank or empty values and "NA" are
+// returned as NaN, numbers stored as text are parsed, and any other value is an error, as with
+// ReadCsvDateFloats.
+func ReadXlsxDateFloats(fp, sheet string) (map[time.Time][]float64, error) {
+	r, err := OpenXLSX(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	rows, err := r.SheetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("ReadXlsxDateFloats: sheet %s is empty", sheet)
+	}
+
+	o, dp := make(map[time.Time][]float64, len(rows)-1), DefaultDateParser.Clone()
+	for i, row := range rows[1:] {
+		if len(row) == 0 || row[0] == nil {
+			continue
+		}
+		var t time.Time
+		switch x := row[0].(type) {
+		case time.Time:
+			t = x
+		case float64:
+			t = r.serialToTime(x)
+		default:
+			if t, err = dp.Parse(xlsxString(x)); err != nil {
+				return nil, fmt.Errorf("date parse error in %s (row %d): %v", fp, i+2, err)
+			}
+		}
+		vs := make([]float64, len(row)-1)
+		for j, v := range row[1:] {
+			switch x := v.(type) {
+			case float64:
+				vs[j] = x
+			case nil:
+				vs[j] = math.NaN()
+			case string:
+				if x = strings.TrimSpace(x); len(x) == 0 || x == "NA" {
+					vs[j] = math.NaN()
+				} else if vs[j], err = strconv.ParseFloat(x, 64); err != nil {
+					return nil, fmt.Errorf("value parse error in %s (cell %s%d): %q is not a number", fp, xlsxColName(j+1), i+2, x)
+				}
+			default:
+				return nil, fmt.Errorf("value parse error in %s (cell %s%d): %v is not a number", fp, xlsxColName(j+1), i+2, x)
+			}
+		}
+		o[t] = vs
+	}
+	return o, nil
+}
@@ -0,0 +1,160 @@
+package mmio
+
+import (
+	"archive/zip"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeXlsxFixture hand-builds a workbook using the parts XLSXwriter does not emit: shared
+// strings (with rich text runs) and the 1904 date system
+func writeXlsxFixture(t *testing.T, fp string) {
+	t.Helper()
+	parts := []struct{ name, xml string }{
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<workbookPr date1904="1"/><sheets><sheet name="obs" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/sharedStrings.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="4" uniqueCount="4">
+<si><t>date</t></si>
+<si><r><rPr><b/></rPr><t>fl</t></r><r><t>ow</t></r></si>
+<si><t>2.5</t></si>
+<si><t></t></si></sst>`},
+		{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<cellXfs count="2"><xf numFmtId="0"/><xf numFmtId="14"/></cellXfs></styleSheet>`},
+		{"xl/worksheets/sheet1.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" s="1"><v>0</v></c><c r="B2" t="s"><v>2</v></c></row>
+<row r="3"><c r="A3"><v>1</v></c><c r="B3" t="s"><v>3</v></c></row>
+<row r="4"><c r="A4" s="1"><v>366.5</v></c><c r="B4" t="inlineStr"><is><t></t></is></c></row>
+</sheetData></worksheet>`},
+	}
+	f, err := os.Create(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(f)
+	for _, p := range parts {
+		w, err := z.Create(p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(p.xml))
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
+
+func TestXLSXreaderFixture(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "fixture.xlsx")
+	writeXlsxFixture(t, fp)
+	d1904 := func(days int, hours time.Duration) time.Time {
+		return time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, days).Add(hours)
+	}
+
+	r, err := OpenXLSX(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	rows, err := r.SheetRows("obs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{
+		{"date", "flow"}, // rich text runs joined
+		{d1904(0, 0), "2.5"},
+		{1., ""},
+		{d1904(366, 12*time.Hour), ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("SheetRows = %v, want %v", rows, want)
+	}
+
+	m, err := ReadXlsxDateFloats(fp, "obs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantm := map[time.Time]float64{
+		d1904(0, 0):              2.5,        // date-formatted cell, shared string number
+		d1904(1, 0):              math.NaN(), // unformatted serial, empty shared string
+		d1904(366, 12*time.Hour): math.NaN(), // empty inline string
+	}
+	if len(m) != len(wantm) {
+		t.Fatalf("read %v, want %v", m, wantm)
+	}
+	for k, w := range wantm {
+		v, ok := m[k]
+		if !ok || len(v) != 1 || (v[0] != w && !(math.IsNaN(v[0]) && math.IsNaN(w))) {
+			t.Errorf("%v: read %v, want %v", k, v, w)
+		}
+	}
+}
+
+func TestReadXlsxDateFloats(t *testing.T) {
+	t0 := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		line    []interface{}
+		want    []float64
+		wantErr string
+	}{
+		{"numbers", []interface{}{t0, 1.5, -2}, []float64{1.5, -2}, ""},
+		{"text date", []interface{}{"2021-06-01", 3.}, []float64{3}, ""},
+		{"blank", []interface{}{t0, nil, 4.}, []float64{math.NaN(), 4}, ""},
+		{"NaN written blank", []interface{}{t0, math.NaN()}, []float64{math.NaN()}, ""},
+		{"NA", []interface{}{t0, "NA"}, []float64{math.NaN()}, ""},
+		{"number as text", []interface{}{t0, " 2.5 "}, []float64{2.5}, ""},
+		{"text", []interface{}{t0, 1., "n/a"}, nil, "cell C2"},
+		{"bool", []interface{}{t0, true}, nil, "cell B2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := filepath.Join(t.TempDir(), "a.xlsx")
+			w := NewXLSXwriter(fp)
+			s, _ := w.AddSheet("obs", "date,a,b")
+			s.WriteLine(tt.line...)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			m, err := ReadXlsxDateFloats(fp, "obs")
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want one naming %s", err, tt.wantErr)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := m[t0]
+			if !ok || len(m) != 1 {
+				t.Fatalf("read %v, want a single row at %v", m, t0)
+			}
+			// rows are padded to the header width
+			for len(got) > len(tt.want) && math.IsNaN(got[len(got)-1]) {
+				got = got[:len(got)-1]
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("read %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] && !(math.IsNaN(got[i]) && math.IsNaN(tt.want[i])) {
+					t.Errorf("read %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
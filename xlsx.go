@@ -0,0 +1,381 @@
+package mmio
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSXwriter is a minimal Excel workbook writer. Sheets are held in memory and the
+// workbook is written on Close.
+type XLSXwriter struct {
+	fp      string
+	sheets  []*XLSXsheet
+	numFmts []string       // custom number format codes, ids from 164
+	xfs     []int          // cellXfs number format ids; index 0 is the default style
+	xfIndex map[string]int // format code to cellXfs index
+}
+
+// XLSXsheet is a named worksheet of an XLSXwriter
+type XLSXsheet struct {
+	Name    string
+	rows    [][]interface{}
+	freeze  bool
+	colFmt  map[int]string
+	dateFmt string
+}
+
+// NewXLSXwriter XLSXwriter constructor
+func NewXLSXwriter(fp string) *XLSXwriter {
+	return &XLSXwriter{fp: fp, xfs: []int{0}, xfIndex: map[string]int{"General": 0}}
+}
+
+// AddSheet adds a worksheet with a comma-delimited header row (no header if empty)
+func (w *XLSXwriter) AddSheet(name, header string) (*XLSXsheet, error) {
+	if len(name) == 0 || len(name) > 31 || strings.ContainsAny(name, `[]:*?/\`) {
+		return nil, fmt.Errorf("XLSXwriter.AddSheet: invalid sheet name %q", name)
+	}
+	for _, s := range w.sheets {
+		if strings.EqualFold(s.Name, name) {
+			return nil, fmt.Errorf("XLSXwriter.AddSheet: duplicate sheet name %q", name)
+		}
+	}
+	s := &XLSXsheet{Name: name, colFmt: make(map[int]string), dateFmt: "yyyy-mm-dd hh:mm:ss"}
+	if len(header) > 0 {
+		h := strings.Split(header, ",")
+		r := make([]interface{}, len(h))
+		for i, c := range h {
+			r[i] = c
+		}
+		s.rows = append(s.rows, r)
+	}
+	w.sheets = append(w.sheets, s)
+	return s, nil
+}
+
+// FreezeHeader freezes the first row of the sheet
+func (s *XLSXsheet) FreezeHeader() {
+	s.freeze = true
+}
+
+// SetNumberFormat sets the Excel number format code (e.g., "0.000", "0.00E+00") of a (0-based) column
+func (s *XLSXsheet) SetNumberFormat(col int, code string) {
+	s.colFmt[col] = code
+}
+
+// SetDateFormat sets the Excel number format code of date cells (default "yyyy-mm-dd hh:mm:ss")
+func (s *XLSXsheet) SetDateFormat(code string) {
+	s.dateFmt = code
+}
+
+// WriteLine adds a row to the sheet; as with CSVwriter.WriteLine, slices are expanded into consecutive columns.
+// Numbers are written as numeric cells, time.Time as native date cells, NaN/Inf and nil as blank cells.
+func (s *XLSXsheet) WriteLine(data ...interface{}) {
+	r := make([]interface{}, 0, len(data))
+	for _, v := range data {
+		switch vv := v.(type) {
+		case []float64:
+			for _, x := range vv {
+				r = append(r, x)
+			}
+		case []float32:
+			for _, x := range vv {
+				r = append(r, x)
+			}
+		case []int:
+			for _, x := range vv {
+				r = append(r, x)
+			}
+		case []int32:
+			for _, x := range vv {
+				r = append(r, x)
+			}
+		case []int64:
+			for _, x := range vv {
+				r = append(r, x)
+			}
+		case []string:
+			for _, x := range vv {
+				r = append(r, x)
+			}
+		case []time.Time:
+			for _, x := range vv {
+				r = append(r, x)
+			}
+		case []interface{}:
+			r = append(r, vv...)
+		default:
+			r = append(r, v)
+		}
+	}
+	s.rows = append(s.rows, r)
+}
+
+// style returns the cellXfs index of a number format code
+func (w *XLSXwriter) style(code string) int {
+	if i, ok := w.xfIndex[code]; ok {
+		return i
+	}
+	w.numFmts = append(w.numFmts, code)
+	w.xfs = append(w.xfs, 163+len(w.numFmts))
+	w.xfIndex[code] = len(w.xfs) - 1
+	return len(w.xfs) - 1
+}
+
+// WriteTo encodes the workbook to out, as an alternative to Close for writers not bound to a file
+func (w *XLSXwriter) WriteTo(out io.Writer) (int64, error) {
+	if len(w.sheets) == 0 {
+		return 0, fmt.Errorf("XLSXwriter.WriteTo: workbook has no sheets")
+	}
+	cw := &countWriter{w: out}
+	if err := w.write(cw); err != nil {
+		return cw.n, fmt.Errorf("XLSXwriter.WriteTo: %v", err)
+	}
+	return cw.n, nil
+}
+
+// countWriter counts the bytes written through it
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Close writes the workbook to file
+func (w *XLSXwriter) Close() error {
+	if len(w.sheets) == 0 {
+		return fmt.Errorf("XLSXwriter.Close: workbook %s has no sheets", w.fp)
+	}
+	f, err := createOutput(w.fp, false)
+	if err != nil {
+		return fmt.Errorf("XLSXwriter.Close: %v", err)
+	}
+	if err := w.write(f); err != nil {
+		f.Abort()
+		return fmt.Errorf("XLSXwriter.Close: %v", err)
+	}
+	return f.Close()
+}
+
+func (w *XLSXwriter) write(out io.Writer) error {
+	z := zip.NewWriter(out)
+	for i, s := range w.sheets { // sheets first, so that all styles are registered
+		if err := w.writeSheet(z, i+1, s); err != nil {
+			return err
+		}
+	}
+
+	var ct, wb, wbr strings.Builder
+	ct.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	wb.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	wbr.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, s := range w.sheets {
+		fmt.Fprintf(&ct, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&wb, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(s.Name), i+1, i+1)
+		fmt.Fprintf(&wbr, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	ct.WriteString(`</Types>`)
+	wb.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&wbr, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(w.sheets)+1)
+
+	var st strings.Builder
+	st.WriteString(xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(w.numFmts) > 0 {
+		fmt.Fprintf(&st, `<numFmts count="%d">`, len(w.numFmts))
+		for i, c := range w.numFmts {
+			fmt.Fprintf(&st, `<numFmt numFmtId="%d" formatCode="%s"/>`, 164+i, xmlEscape(c))
+		}
+		st.WriteString(`</numFmts>`)
+	}
+	st.WriteString(`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+	fmt.Fprintf(&st, `<cellXfs count="%d">`, len(w.xfs))
+	for _, id := range w.xfs {
+		fmt.Fprintf(&st, `<xf numFmtId="%d" fontId="0" fillId="0" borderId="0" xfId="0"`, id)
+		if id > 0 {
+			st.WriteString(` applyNumberFormat="1"`)
+		}
+		st.WriteString(`/>`)
+	}
+	st.WriteString(`</cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", ct.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", wb.String()},
+		{"xl/_rels/workbook.xml.rels", wbr.String()},
+		{"xl/styles.xml", st.String()},
+	}
+	for _, p := range parts {
+		pw, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(pw, p.body); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func (w *XLSXwriter) writeSheet(z *zip.Writer, n int, s *XLSXsheet) error {
+	zw, err := z.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", n))
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(zw)
+	b.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if s.freeze {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+			`<selection pane="bottomLeft"/></sheetView></sheetViews>`)
+	}
+	b.WriteString(`<sheetData>`)
+	cols := make([]int, 0, len(s.colFmt))
+	for c := range s.colFmt {
+		cols = append(cols, c)
+	}
+	sort.Ints(cols) // styles are numbered in order of first use: keep the output reproducible
+	colStyle := make(map[int]int, len(cols))
+	for _, c := range cols {
+		colStyle[c] = w.style(s.colFmt[c])
+	}
+	dateStyle := w.style(s.dateFmt)
+	for i, r := range s.rows {
+		fmt.Fprintf(b, `<row r="%d">`, i+1)
+		for j, v := range r {
+			ref := xlsxColName(j) + strconv.Itoa(i+1)
+			switch vv := v.(type) {
+			case nil:
+			case string:
+				fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(vv))
+			case bool:
+				x := 0
+				if vv {
+					x = 1
+				}
+				fmt.Fprintf(b, `<c r="%s" t="b"><v>%d</v></c>`, ref, x)
+			case time.Time:
+				fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, dateStyle, strconv.FormatFloat(TimeToExcelSerial(vv), 'f', -1, 64))
+			default:
+				x, ok := xlsxNumber(v)
+				if !ok {
+					fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(fmt.Sprint(v)))
+					continue
+				}
+				if math.IsNaN(x) || math.IsInf(x, 0) {
+					continue
+				}
+				if st, ok := colStyle[j]; ok {
+					fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, st, strconv.FormatFloat(x, 'g', -1, 64))
+				} else {
+					fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(x, 'g', -1, 64))
+				}
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Flush()
+}
+
+func xlsxNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int8:
+		return float64(x), true
+	case int16:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint8:
+		return float64(x), true
+	case uint16:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+// xlsxColName converts a 0-based column index to its spreadsheet name (A, B, ..., Z, AA, ...)
+func xlsxColName(j int) string {
+	s := ""
+	for j++; j > 0; j = (j - 1) / 26 {
+		s = string(rune('A'+(j-1)%26)) + s
+	}
+	return s
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// WriteXLSX writes a single-sheet workbook from a complete dataset, as WriteCSV
+func WriteXLSX(fp, sheet, h string, d ...[]interface{}) error {
+	w := NewXLSXwriter(fp)
+	s, err := w.AddSheet(sheet, h)
+	if err != nil {
+		return err
+	}
+	s.FreezeHeader()
+	if len(d) > 0 {
+		for i := range d[0] {
+			r := make([]interface{}, len(d))
+			for j := range d {
+				r[j] = d[j][i]
+			}
+			s.WriteLine(r...)
+		}
+	}
+	return w.Close()
+}
+
+// WriteXlsxDateFloats writes a single-sheet workbook of dated values, as WriteCsvDateFloats
+func WriteXlsxDateFloats(fp, sheet, header string, t []time.Time, d ...[]float64) error {
+	w := NewXLSXwriter(fp)
+	s, err := w.AddSheet(sheet, "date,"+header)
+	if err != nil {
+		return err
+	}
+	s.FreezeHeader()
+	for i := range t {
+		r := make([]interface{}, len(d)+1)
+		r[0] = t[i]
+		for j := range d {
+			r[j+1] = d[j][i]
+		}
+		s.WriteLine(r...)
+	}
+	return w.Close()
+}
@@ -0,0 +1,85 @@
+package mmio
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestXLSXRoundTrip(t *testing.T) {
+	t0 := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name string
+		line []interface{}
+		want []interface{}
+	}{
+		{"numbers", []interface{}{1.5, 2, int64(-3), float32(0.25)}, []interface{}{1.5, 2., -3., 0.25}},
+		{"slices", []interface{}{[]float64{1, 2}, []string{"a", "b"}}, []interface{}{1., 2., "a", "b"}},
+		{"text", []interface{}{"a<b & c", " padded "}, []interface{}{"a<b & c", " padded "}},
+		{"bool", []interface{}{true, false}, []interface{}{true, false}},
+		{"date", []interface{}{t0}, []interface{}{t0}},
+		{"blank", []interface{}{nil, 1.}, []interface{}{nil, 1.}},
+	}
+	fp := filepath.Join(t.TempDir(), "a.xlsx")
+	w := NewXLSXwriter(fp)
+	for _, tt := range tests {
+		s, err := w.AddSheet(tt.name, "h1,h2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.FreezeHeader()
+		s.SetNumberFormat(1, "0.000")
+		s.WriteLine(tt.line...)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenXLSX(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := r.SheetRows(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("%d rows, want 2", len(rows))
+			}
+			got := rows[1]
+			for len(got) > len(tt.want) && got[len(got)-1] == nil {
+				got = got[:len(got)-1] // padded to the header width
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXReproducible(t *testing.T) {
+	build := func() []byte {
+		w := NewXLSXwriter("")
+		s, _ := w.AddSheet("a", "c0,c1,c2,c3,c4,c5")
+		for c, code := range []string{"0.0", "0.00", "0.000", "0.00E+00", "0%", "#,##0"} {
+			s.SetNumberFormat(c, code)
+		}
+		s.WriteLine([]float64{1, 2, 3, 4, 5, 6})
+		var buf bytes.Buffer
+		if err := w.write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	b := build()
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(build(), b) {
+			t.Fatal("workbook bytes differ between runs")
+		}
+	}
+}